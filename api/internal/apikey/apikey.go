@@ -0,0 +1,91 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"test-api/kit/auth"
+)
+
+// =================================================================================
+// Modèles de Données (Entities & DTOs)
+// =================================================================================
+
+// APIKey est une clé d'intégration machine à machine, propre à un tenant.
+// Seule l'empreinte du secret est stockée : la clé complète n'est montrée qu'une fois, à la création.
+type APIKey struct {
+	// TenantID est la clé de partition.
+	TenantID string `json:"tenantID"`
+	ID       string `json:"id"`
+
+	// Name permet au client de reconnaître l'intégration (ex : "Synchro Sage").
+	Name string `json:"name"`
+
+	// Scopes sont les permissions accordées à la clé (ex : "users:read").
+	Scopes []string `json:"scopes"`
+
+	// SecretHash est le SHA-256 du secret. Le secret est aléatoire (256 bits) :
+	// un hachage lent type argon2 n'apporterait rien, contrairement aux mots de passe.
+	SecretHash string `json:"secretHash"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	CreatedBy  string     `json:"createdBy"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy  string     `json:"revokedBy,omitempty"`
+
+	ETag string `json:"_etag,omitempty"`
+}
+
+// GetID retourne l'identifiant unique.
+func (k APIKey) GetID() string {
+	return k.ID
+}
+
+// GetTenantID retourne la clé de partition.
+func (k APIKey) GetTenantID() string {
+	return k.TenantID
+}
+
+// GetETag retourne la version du document (implémente database.Versioned).
+func (k APIKey) GetETag() string {
+	return k.ETag
+}
+
+// SetETag met à jour la version du document après une écriture.
+func (k *APIKey) SetETag(etag string) {
+	k.ETag = etag
+}
+
+// IsActive indique si la clé peut encore être utilisée.
+func (k APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// CreateKeyInput est le DTO de création.
+type CreateKeyInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// =================================================================================
+// Interfaces (Contrats)
+// =================================================================================
+
+// Service gère le cycle de vie des clés. Il implémente aussi auth.APIKeyVerifier,
+// ce qui permet au middleware d'authentification d'accepter les clés.
+type Service interface {
+	// CreateKey retourne la clé enregistrée et son secret complet, qui ne pourra plus être relu.
+	CreateKey(ctx context.Context, creator *auth.Principal, input CreateKeyInput) (*APIKey, string, error)
+	ListKeys(ctx context.Context, tenantID string) ([]APIKey, error)
+	RevokeKey(ctx context.Context, tenantID string, id string, revokedBy string) (*APIKey, error)
+	// FlushUsage écrit en base les dates de dernière utilisation retenues par VerifyAPIKey.
+	FlushUsage(ctx context.Context)
+
+	auth.APIKeyVerifier
+}
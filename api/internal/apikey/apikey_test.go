@@ -0,0 +1,222 @@
+package apikey_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/internal/apikey"
+	"test-api/kit/auth"
+	"test-api/kit/database/memory"
+	"test-api/kit/health"
+)
+
+var testSecret = []byte("test-secret")
+
+// newTestServer monte les routes /api-keys et deux routes sondes derrière le vrai middleware d'auth.
+func newTestServer(t *testing.T) (http.Handler, *memory.Adapter[apikey.APIKey]) {
+	t.Helper()
+	adapter := memory.NewAdapter[apikey.APIKey]()
+	service := apikey.NewService(adapter)
+
+	authenticator, err := auth.NewHMACAuthenticator(testSecret, auth.WithIssuers("test-api"), auth.WithAPIKeys(service))
+	require.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	r := chi.NewRouter()
+	r.Use(authenticator.Middleware)
+	r.Route("/api-keys", apikey.NewHandler(service).RegisterRoutes)
+	r.With(auth.Require("users:read")).Get("/probe", ok)
+	r.With(auth.Require("users:write")).Post("/probe", ok)
+	return r, adapter
+}
+
+func bearer(t *testing.T, tenantID, role string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   "test-api",
+		"sub":   "admin-1",
+		"tid":   tenantID,
+		"roles": []string{role},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testSecret)
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func do(h http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+type keyResponse struct {
+	ID         string     `json:"id"`
+	Scopes     []string   `json:"scopes"`
+	Secret     string     `json:"secret"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+func TestAPIKey_Lifecycle(t *testing.T) {
+	h, _ := newTestServer(t)
+	admin := http.Header{"Authorization": {bearer(t, "tenant-A", auth.RoleTenantAdmin)}}
+
+	// 1. Création : le secret n'est renvoyé qu'une fois.
+	rr := do(h, http.MethodPost, "/api-keys", `{"name": "Synchro Sage", "scopes": ["users:read"]}`, admin)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	var created keyResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+	require.NotEmpty(t, created.Secret)
+
+	// 2. La clé authentifie, avec ses seuls scopes, via les deux en-têtes acceptés.
+	assert.Equal(t, http.StatusOK, do(h, http.MethodGet, "/probe", "", http.Header{"X-Api-Key": {created.Secret}}).Code)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodGet, "/probe", "", http.Header{"Authorization": {"ApiKey " + created.Secret}}).Code)
+	assert.Equal(t, http.StatusForbidden, do(h, http.MethodPost, "/probe", "", http.Header{"X-Api-Key": {created.Secret}}).Code)
+	assert.Equal(t, http.StatusForbidden, do(h, http.MethodGet, "/api-keys", "", http.Header{"X-Api-Key": {created.Secret}}).Code,
+		"une clé ne gère pas les clés")
+
+	// 3. Un secret altéré est refusé.
+	assert.Equal(t, http.StatusUnauthorized, do(h, http.MethodGet, "/probe", "", http.Header{"X-Api-Key": {created.Secret + "x"}}).Code)
+
+	// 4. La liste n'expose ni secret ni empreinte, mais la date de dernière utilisation.
+	rr = do(h, http.MethodGet, "/api-keys", "", admin)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "secret")
+	var listed []keyResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.NotNil(t, listed[0].LastUsedAt)
+
+	// 5. Révocation : la clé cesse immédiatement de fonctionner.
+	require.Equal(t, http.StatusNoContent, do(h, http.MethodDelete, "/api-keys/"+created.ID, "", admin).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(h, http.MethodGet, "/probe", "", http.Header{"X-Api-Key": {created.Secret}}).Code)
+}
+
+func TestAPIKey_CreateErrors(t *testing.T) {
+	h, _ := newTestServer(t)
+
+	tests := []struct {
+		name           string
+		role           string
+		body           string
+		expectedStatus int
+	}{
+		{"Nom et scopes manquants", auth.RoleTenantAdmin, `{}`, http.StatusBadRequest},
+		{"Expiration passée", auth.RoleTenantAdmin, `{"name": "x", "scopes": ["users:read"], "expiresAt": "2020-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"Membre sans droit de gestion", auth.RoleMember, `{"name": "x", "scopes": ["users:read"]}`, http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := do(h, http.MethodPost, "/api-keys", tc.body, http.Header{"Authorization": {bearer(t, "tenant-A", tc.role)}})
+			assert.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestAPIKey_ScopesLimitedToCreator(t *testing.T) {
+	service := apikey.NewService(memory.NewAdapter[apikey.APIKey]())
+	manager := &auth.Principal{Subject: "m-1", TenantID: "tenant-A", Permissions: []string{"users:read", "users:write", "api-keys:write"}}
+
+	_, _, err := service.CreateKey(t.Context(), manager, apikey.CreateKeyInput{Name: "x", Scopes: []string{"*"}})
+	assert.ErrorIs(t, err, apikey.ErrScopeNotAllowed)
+}
+
+func TestAPIKey_CannotCreateKeys(t *testing.T) {
+	service := apikey.NewService(memory.NewAdapter[apikey.APIKey]())
+	admin := &auth.Principal{Subject: "admin-1", TenantID: "tenant-A", Permissions: []string{"*"}}
+
+	_, secret, err := service.CreateKey(t.Context(), admin, apikey.CreateKeyInput{Name: "Automate", Scopes: []string{"*"}})
+	require.NoError(t, err)
+	principal, err := service.VerifyAPIKey(t.Context(), secret)
+	require.NoError(t, err)
+
+	// Même avec tous les droits, une clé ne se duplique pas.
+	_, _, err = service.CreateKey(t.Context(), principal, apikey.CreateKeyInput{Name: "Copie", Scopes: []string{"users:read"}})
+	assert.ErrorIs(t, err, apikey.ErrCreatedByKey)
+}
+
+func TestAPIKey_ExpiredAndCrossTenant(t *testing.T) {
+	adapter := memory.NewAdapter[apikey.APIKey]()
+	service := apikey.NewService(adapter)
+	admin := &auth.Principal{Subject: "a-1", TenantID: "tenant-A", Permissions: []string{"*"}}
+
+	key, secret, err := service.CreateKey(t.Context(), admin, apikey.CreateKeyInput{Name: "x", Scopes: []string{"users:read"}})
+	require.NoError(t, err)
+
+	p, err := service.VerifyAPIKey(t.Context(), secret)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-A", p.TenantID)
+
+	// Expiration atteinte (on modifie directement le document).
+	stored, err := adapter.Read(t.Context(), key.ID, "tenant-A")
+	require.NoError(t, err)
+	past := time.Now().Add(-time.Minute)
+	stored.ExpiresAt = &past
+	_, err = adapter.Update(t.Context(), stored)
+	require.NoError(t, err)
+
+	_, err = service.VerifyAPIKey(t.Context(), secret)
+	assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+
+	// Une clé révoquée par un autre tenant n'existe pas pour lui.
+	_, err = service.RevokeKey(t.Context(), "tenant-B", key.ID, "b-1")
+	assert.ErrorIs(t, err, apikey.ErrKeyNotFound)
+}
+
+func TestAPIKey_UsageFlushedInBackground(t *testing.T) {
+	adapter := memory.NewAdapter[apikey.APIKey]()
+	service := apikey.NewService(adapter)
+	admin := &auth.Principal{Subject: "a-1", TenantID: "tenant-A", Permissions: []string{"*"}}
+
+	key, secret, err := service.CreateKey(t.Context(), admin, apikey.CreateKeyInput{Name: "x", Scopes: []string{"users:read"}})
+	require.NoError(t, err)
+	_, err = service.VerifyAPIKey(t.Context(), secret)
+	require.NoError(t, err)
+
+	// L'authentification n'écrit pas en base ; la liste montre déjà l'utilisation.
+	stored, err := adapter.Read(t.Context(), key.ID, "tenant-A")
+	require.NoError(t, err)
+	assert.Nil(t, stored.LastUsedAt)
+	listed, err := service.ListKeys(t.Context(), "tenant-A")
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.NotNil(t, listed[0].LastUsedAt)
+
+	// La tâche de fond du module est déclarée en liveness et écrit une dernière fois à l'arrêt.
+	module := apikey.NewModule(service)
+	checks := health.NewRegistry()
+	module.RegisterHealthChecks(checks)
+	report := checks.Live(t.Context())
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Contains(t, report.Checks, "job:api-keys/usage")
+
+	jobs := module.Jobs()
+	require.Len(t, jobs, 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- jobs[0].Run(ctx) }()
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	stored, err = adapter.Read(t.Context(), key.ID, "tenant-A")
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.True(t, stored.LastUsedAt.Equal(*listed[0].LastUsedAt))
+}
@@ -0,0 +1,140 @@
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+	"test-api/kit/auth"
+)
+
+// Permissions du domaine API keys (accordées au rôle tenant-admin via "*").
+const (
+	PermissionRead  = "api-keys:read"
+	PermissionWrite = "api-keys:write"
+)
+
+// Handler gère les requêtes HTTP pour les clés d'API.
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// RegisterRoutes définit les points d'entrée HTTP pour le module API keys.
+//
+// POST /api-keys : Création d'une clé (le secret n'est renvoyé qu'à cette occasion)
+// GET /api-keys : Liste des clés du tenant (sans secret)
+// DELETE /api-keys/{id} : Révocation d'une clé
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.With(auth.Require(PermissionRead)).Get("/", h.List)
+
+	write := r.With(auth.Require(PermissionWrite))
+	write.Post("/", h.Create)
+	write.Delete("/{id}", h.Revoke)
+}
+
+// keyResponse est la représentation publique d'une clé : l'empreinte n'est jamais exposée.
+type keyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	CreatedBy  string     `json:"createdBy"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+
+	// Secret est la clé complète, présente uniquement dans la réponse de création.
+	Secret string `json:"secret,omitempty"`
+}
+
+func newKeyResponse(k APIKey) keyResponse {
+	return keyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		CreatedBy:  k.CreatedBy,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+// =================================================================================
+// Handlers HTTP
+// =================================================================================
+
+// Create POST /api-keys
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFrom(r.Context())
+	if !ok {
+		api.RespondWithError(w, r, auth.ErrNoPrincipal)
+		return
+	}
+
+	var input CreateKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
+		return
+	}
+	defer r.Body.Close()
+
+	key, secret, err := h.service.CreateKey(r.Context(), principal, input)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	res := newKeyResponse(*key)
+	res.Secret = secret
+	// La réponse contient un secret : elle ne doit être conservée par aucun cache.
+	w.Header().Set("Cache-Control", "no-store")
+	api.RespondWithJSON(w, r, http.StatusCreated, res)
+}
+
+// List GET /api-keys
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := auth.TenantID(r.Context())
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	keys, err := h.service.ListKeys(r.Context(), tenantID)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	res := make([]keyResponse, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, newKeyResponse(k))
+	}
+	api.RespondWithJSON(w, r, http.StatusOK, res)
+}
+
+// Revoke DELETE /api-keys/{id}
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := auth.TenantID(r.Context())
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	if _, err := h.service.RevokeKey(r.Context(), tenantID, chi.URLParam(r, "id"), auth.UserID(r.Context())); err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
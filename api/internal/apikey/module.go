@@ -0,0 +1,67 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"test-api/internal/server"
+	"test-api/kit/health"
+)
+
+// ModuleName identifie le domaine API keys auprès des autres modules (server.Module).
+const ModuleName = "api-keys"
+
+// usageFlushTimeout borne la dernière écriture des utilisations, à l'arrêt du serveur.
+const usageFlushTimeout = 5 * time.Second
+
+// Module expose la gestion des clés d'API au serveur : routes /api-keys, et la tâche de fond
+// qui écrit en base les dates de dernière utilisation.
+type Module struct {
+	handler *Handler
+	service Service
+	// usageHeartbeat signale en liveness une tâche d'écriture bloquée ou arrêtée.
+	usageHeartbeat *health.Heartbeat
+}
+
+func NewModule(s Service) *Module {
+	return &Module{
+		handler:        NewHandler(s),
+		service:        s,
+		usageHeartbeat: health.NewHeartbeat(3 * lastUsedResolution),
+	}
+}
+
+func (m *Module) Name() string           { return ModuleName }
+func (m *Module) MountPath() string      { return "/api-keys" }
+func (m *Module) Dependencies() []string { return nil }
+
+func (m *Module) RegisterRoutes(r chi.Router) { m.handler.RegisterRoutes(r) }
+
+func (m *Module) RegisterHealthChecks(checks *health.Registry) {
+	checks.Register("job:"+ModuleName+"/usage", m.usageHeartbeat, health.Liveness(), health.WithCacheTTL(0))
+}
+
+func (m *Module) Jobs() []server.Job {
+	return []server.Job{{Name: "usage", Run: m.flushUsage}}
+}
+
+// flushUsage écrit les utilisations retenues à chaque période, puis une dernière fois à l'arrêt.
+func (m *Module) flushUsage(ctx context.Context) error {
+	ticker := time.NewTicker(lastUsedResolution)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageFlushTimeout)
+			m.service.FlushUsage(flushCtx)
+			cancel()
+			return ctx.Err()
+		case <-ticker.C:
+			m.service.FlushUsage(ctx)
+			m.usageHeartbeat.Beat()
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package apikey
+
+import (
+	"net/http"
+
+	"test-api/kit/openapi"
+)
+
+// DescribeRoutes décrit les routes du module dans le document OpenAPI (voir RegisterRoutes) :
+// routes est relatif à /api/api-keys. Le module n'a pas de routes publiques.
+func (m *Module) DescribeRoutes(routes, _ *openapi.Spec) {
+	routes.Add(http.MethodPost, "/", openapi.Operation{
+		OperationID: "createAPIKey",
+		Summary:     "Crée une clé d'API (le secret n'est renvoyé qu'à cette occasion)",
+		RequestBody: routes.Body("application/json", CreateKeyInput{}),
+		Responses:   map[string]*openapi.Response{"201": routes.JSON("Clé créée, avec son secret", keyResponse{})},
+	})
+	routes.Add(http.MethodGet, "/", openapi.Operation{
+		OperationID: "listAPIKeys",
+		Summary:     "Liste les clés du tenant (sans secret)",
+		Responses:   map[string]*openapi.Response{"200": routes.JSON("Clés", []keyResponse{})},
+	})
+	routes.Add(http.MethodDelete, "/{id}", openapi.Operation{
+		OperationID: "revokeAPIKey",
+		Summary:     "Révoque une clé",
+		Responses:   map[string]*openapi.Response{"204": openapi.NoContent("Clé révoquée")},
+	})
+}
@@ -0,0 +1,219 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-api/kit/apperr"
+	"test-api/kit/auth"
+	"test-api/kit/database"
+	"test-api/kit/logger"
+)
+
+// Préfixe des clés (auth.OpaqueToken) : erp_<tenant en base64url>.<id>.<secret>.
+const keyPrefix = "erp_"
+
+// subjectPrefix préfixe le Subject des principaux authentifiés par une clé ("apikey:<id>").
+const subjectPrefix = "apikey:"
+
+// lastUsedResolution évite d'écrire en base à chaque requête authentifiée par la même clé :
+// c'est aussi la période d'écriture des utilisations retenues (voir FlushUsage).
+const lastUsedResolution = time.Minute
+
+// -- Définition des erreurs métier --
+
+var ErrKeyNotFound = apperr.New(apperr.ErrNotFound, "API key not found")
+var ErrInvalidKey = apperr.New(apperr.ErrUnauthorized, "invalid API key")
+var ErrScopeNotAllowed = apperr.New(apperr.ErrForbidden, "cannot grant a scope you do not have")
+var ErrCreatedByKey = apperr.New(apperr.ErrForbidden, "an API key cannot create API keys")
+
+type serviceImpl struct {
+	repo database.Repository[APIKey]
+	now  func() time.Time
+
+	// usage retient la dernière utilisation des clés, en attendant son écriture par FlushUsage.
+	usageMu sync.Mutex
+	usage   map[usageKey]time.Time
+}
+
+type usageKey struct {
+	tenantID string
+	id       string
+}
+
+// NewService crée le service à partir de l'adaptateur générique (cosmos.Adapter en production).
+func NewService(repo database.Repository[APIKey]) Service {
+	return &serviceImpl{
+		repo:  repo,
+		now:   time.Now,
+		usage: make(map[usageKey]time.Time),
+	}
+}
+
+// CreateKey valide la demande, génère le secret et n'en stocke que l'empreinte.
+func (s *serviceImpl) CreateKey(ctx context.Context, creator *auth.Principal, input CreateKeyInput) (*APIKey, string, error) {
+	// Une clé qui en crée d'autres survivrait à sa propre révocation ou expiration.
+	if strings.HasPrefix(creator.Subject, subjectPrefix) {
+		return nil, "", ErrCreatedByKey
+	}
+
+	var errs []error
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		errs = append(errs, apperr.InvalidField("name", "cannot be empty"))
+	}
+	if len(input.Scopes) == 0 {
+		errs = append(errs, apperr.InvalidField("scopes", "at least one scope is required"))
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		errs = append(errs, apperr.InvalidField("expiresAt", "must be in the future"))
+	}
+	if len(errs) > 0 {
+		return nil, "", errors.Join(errs...)
+	}
+
+	// Une clé ne peut pas donner plus de droits que son créateur n'en a.
+	for _, scope := range input.Scopes {
+		if !creator.Can(scope) {
+			return nil, "", fmt.Errorf("%w: %q", ErrScopeNotAllowed, scope)
+		}
+	}
+
+	token, err := auth.NewOpaqueToken(creator.TenantID, uuid.NewString())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key secret: %w", err)
+	}
+
+	key := APIKey{
+		TenantID:   token.TenantID,
+		ID:         token.ID,
+		Name:       name,
+		Scopes:     input.Scopes,
+		SecretHash: token.Hash(),
+		CreatedAt:  s.now().UTC(),
+		CreatedBy:  creator.Subject,
+		ExpiresAt:  input.ExpiresAt,
+	}
+
+	created, err := s.repo.Create(ctx, key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API key in repo: %w", err)
+	}
+
+	return &created, token.Format(keyPrefix), nil
+}
+
+// ListKeys retourne toutes les clés du tenant, y compris révoquées, les plus récentes d'abord.
+func (s *serviceImpl) ListKeys(ctx context.Context, tenantID string) ([]APIKey, error) {
+	q := database.NewQuery[APIKey]().
+		Where(database.Eq("tenantID", tenantID)).
+		OrderBy(database.Desc("createdAt"))
+
+	page, err := s.repo.Search(ctx, q, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	// Les utilisations pas encore écrites en base sont déjà visibles.
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+	for i := range page.Items {
+		if used, ok := s.usage[usageKey{tenantID, page.Items[i].ID}]; ok {
+			page.Items[i].LastUsedAt = &used
+		}
+	}
+	return page.Items, nil
+}
+
+// RevokeKey désactive définitivement la clé. Sans effet si elle l'est déjà.
+func (s *serviceImpl) RevokeKey(ctx context.Context, tenantID string, id string, revokedBy string) (*APIKey, error) {
+	key, err := s.repo.Read(ctx, id, tenantID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to read API key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return &key, nil
+	}
+
+	now := s.now().UTC()
+	key.RevokedAt = &now
+	key.RevokedBy = revokedBy
+
+	updated, err := s.repo.Update(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key in repo: %w", err)
+	}
+	return &updated, nil
+}
+
+// VerifyAPIKey implémente auth.APIKeyVerifier : le principal obtenu porte les scopes de la clé.
+func (s *serviceImpl) VerifyAPIKey(ctx context.Context, raw string) (*auth.Principal, error) {
+	token, ok := auth.ParseOpaqueToken(keyPrefix, raw)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed key", ErrInvalidKey)
+	}
+
+	id := token.ID
+	key, err := s.repo.Read(ctx, id, token.TenantID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("%w: unknown key %s", ErrInvalidKey, id)
+		}
+		return nil, fmt.Errorf("failed to read API key: %w", err)
+	}
+
+	if !token.Matches(key.SecretHash) {
+		return nil, fmt.Errorf("%w: secret mismatch for key %s", ErrInvalidKey, id)
+	}
+	now := s.now()
+	if !key.IsActive(now) {
+		return nil, fmt.Errorf("%w: key %s is revoked or expired", ErrInvalidKey, id)
+	}
+
+	// L'écriture est différée (FlushUsage) : l'authentification ne coûte pas d'écriture en base.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		s.usageMu.Lock()
+		s.usage[usageKey{key.TenantID, key.ID}] = now.UTC()
+		s.usageMu.Unlock()
+	}
+
+	return &auth.Principal{
+		Subject:     subjectPrefix + key.ID,
+		TenantID:    key.TenantID,
+		Permissions: key.Scopes,
+	}, nil
+}
+
+// FlushUsage écrit en base les utilisations retenues depuis l'appel précédent.
+// Mise à jour "au mieux" : un échec (écriture concurrente, throttling) est journalisé, sans nouvel essai.
+func (s *serviceImpl) FlushUsage(ctx context.Context) {
+	s.usageMu.Lock()
+	pending := s.usage
+	s.usage = make(map[usageKey]time.Time)
+	s.usageMu.Unlock()
+
+	for k, used := range pending {
+		key, err := s.repo.Read(ctx, k.id, k.tenantID)
+		if err != nil {
+			logger.Warn(ctx, "Failed to record API key usage", "apiKeyID", k.id, "error", err)
+			continue
+		}
+		if key.LastUsedAt != nil && !used.After(*key.LastUsedAt) {
+			continue
+		}
+		key.LastUsedAt = &used
+		if _, err := s.repo.Update(ctx, key); err != nil {
+			logger.Warn(ctx, "Failed to record API key usage", "apiKeyID", k.id, "error", err)
+		}
+	}
+}
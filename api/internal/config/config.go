@@ -0,0 +1,284 @@
+// Package config charge la configuration typée de l'API : valeurs par défaut, fichier optionnel
+// (CONFIG_FILE) puis variables d'environnement, qui ont toujours le dernier mot.
+// Toutes les erreurs sont collectées et rapportées ensemble : le démarrage échoue d'un coup,
+// avec la liste complète de ce qui est à corriger.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// Config regroupe tous les réglages de l'API. Chaque champ porte le nom de sa variable
+// d'environnement (tag env) et, le cas échéant, sa valeur par défaut (tag default).
+type Config struct {
+	Server     Server
+	Log        Log
+	Telemetry  Telemetry
+	Cosmos     Cosmos
+	Auth       Auth
+	Invitation Invitation
+	Pagination Pagination
+	Modules    Modules
+}
+
+// Server règle le serveur HTTP.
+type Server struct {
+	Port              int           `env:"FUNCTIONS_CUSTOMHANDLER_PORT" default:"8080"`
+	ReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" default:"5s"`
+	ReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" default:"2s"`
+	WriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT" default:"10s"`
+	IdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	MaxHeaderBytes    int           `env:"SERVER_MAX_HEADER_BYTES" default:"1048576"`
+	// DrainDelay et ShutdownTimeout règlent l'arrêt gracieux (voir server.ShutdownConfig).
+	// L'hôte Azure Functions laisse environ 30 s entre SIGTERM et l'arrêt forcé.
+	DrainDelay      time.Duration `env:"SERVER_DRAIN_DELAY" default:"0s"`
+	ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" default:"25s"`
+}
+
+// Log règle kit/logger.
+type Log struct {
+	Level            string  `env:"LOG_LEVEL" default:"info"`
+	Format           string  `env:"LOG_FORMAT" default:"json"`
+	AccessSampleRate float64 `env:"LOG_ACCESS_SAMPLE_RATE" default:"1"`
+}
+
+// Telemetry règle les traces (kit/tracing) et les métriques (kit/metrics).
+// Les variables OTEL_EXPORTER_OTLP_* sont lues directement par les exporteurs OTLP.
+type Telemetry struct {
+	ServiceName       string   `env:"OTEL_SERVICE_NAME" default:"test-api"`
+	TracesExporter    string   `env:"OTEL_TRACES_EXPORTER" default:"none"`
+	TracesSampleRatio float64  `env:"OTEL_TRACES_SAMPLER_ARG" default:"1"`
+	MetricsExporters  []string `env:"OTEL_METRICS_EXPORTER" default:"prometheus"`
+	// MetricsToken protège /metrics ; vide, l'endpoint n'est pas exposé.
+	MetricsToken Secret `env:"METRICS_TOKEN"`
+}
+
+// Cosmos règle la base de données. Sans Endpoint, l'API utilise la base en mémoire.
+type Cosmos struct {
+	Endpoint               string `env:"COSMOS_ENDPOINT"`
+	Database               string `env:"COSMOS_DATABASE" default:"TestDB"`
+	UsersContainer         string `env:"COSMOS_USERS_CONTAINER" default:"UsersContainer"`
+	APIKeysContainer       string `env:"COSMOS_API_KEYS_CONTAINER" default:"ApiKeysContainer"`
+	RefreshTokensContainer string `env:"COSMOS_REFRESH_TOKENS_CONTAINER" default:"RefreshTokensContainer"`
+}
+
+// InMemory indique que la base en mémoire est utilisée (lancement local).
+func (c Cosmos) InMemory() bool {
+	return c.Endpoint == ""
+}
+
+// Auth règle la validation des jetons : JWKS (Entra ID) si JWKSURL ou JWKSFile est renseigné,
+// sinon jetons HMAC signés avec JWTSecret.
+type Auth struct {
+	JWTSecret Secret        `env:"JWT_SECRET"`
+	JWKSURL   string        `env:"AUTH_JWKS_URL"`
+	JWKSFile  string        `env:"AUTH_JWKS_FILE"`
+	Issuers   []string      `env:"AUTH_ISSUERS"`
+	Audience  string        `env:"AUTH_AUDIENCE"`
+	ClockSkew time.Duration `env:"AUTH_CLOCK_SKEW" default:"1m"`
+	RolesFile string        `env:"AUTH_ROLES_FILE"`
+}
+
+// UsesJWKS indique que les jetons sont signés par un fournisseur d'identité externe.
+func (a Auth) UsesJWKS() bool {
+	return a.JWKSURL != "" || a.JWKSFile != ""
+}
+
+// Invitation règle l'envoi des invitations.
+type Invitation struct {
+	// SigningKey est obligatoire avec Cosmos ; en mémoire, une clé aléatoire est générée.
+	SigningKey Secret        `env:"INVITATION_SIGNING_KEY"`
+	BaseURL    string        `env:"INVITATION_BASE_URL" default:"/invitations/"`
+	TTL        time.Duration `env:"INVITATION_TTL" default:"168h"`
+	// Dir dépose les invitations dans des fichiers au lieu des logs.
+	Dir string `env:"INVITATION_DIR"`
+}
+
+// Pagination règle la signature des curseurs.
+type Pagination struct {
+	// CursorSigningKey est obligatoire avec Cosmos ; en mémoire, une clé aléatoire est générée.
+	CursorSigningKey Secret `env:"CURSOR_SIGNING_KEY"`
+}
+
+// Modules choisit les domaines métier montés par le routeur (noms des server.Module, ex :
+// "users,api-keys"). Vide : tous ceux que permet la configuration d'authentification.
+type Modules struct {
+	Enabled []string `env:"MODULES"`
+}
+
+// =================================================================================
+// Secrets
+// =================================================================================
+
+// Secret est une valeur sensible : elle n'apparaît jamais en clair quand la configuration
+// est affichée ou journalisée (fmt, JSON, slog). Value donne la valeur réelle.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+func (s Secret) Value() string { return string(s) }
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s Secret) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+// String affiche la configuration effective, secrets masqués.
+func (c Config) String() string {
+	b, _ := json.MarshalIndent(c, "", "  ")
+	return string(b)
+}
+
+// =================================================================================
+// Validation
+// =================================================================================
+
+// Validate vérifie la cohérence de la configuration et retourne toutes les erreurs trouvées.
+func (c Config) Validate() error {
+	var errs []error
+	for _, fe := range c.validate() {
+		errs = append(errs, fe)
+	}
+	return errors.Join(errs...)
+}
+
+// validate retourne un FieldError par réglage incohérent.
+func (c Config) validate() []*FieldError {
+	var errs []*FieldError
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, &FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		invalid("FUNCTIONS_CUSTOMHANDLER_PORT", "must be between 1 and 65535")
+	}
+	if c.Server.ReadTimeout <= 0 {
+		invalid("SERVER_READ_TIMEOUT", "must be positive")
+	}
+	if c.Server.ReadHeaderTimeout <= 0 {
+		invalid("SERVER_READ_HEADER_TIMEOUT", "must be positive")
+	}
+	if c.Server.WriteTimeout <= 0 {
+		invalid("SERVER_WRITE_TIMEOUT", "must be positive")
+	}
+	if c.Server.IdleTimeout <= 0 {
+		invalid("SERVER_IDLE_TIMEOUT", "must be positive")
+	}
+	if c.Server.MaxHeaderBytes < 4096 {
+		invalid("SERVER_MAX_HEADER_BYTES", "must be at least 4096")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		invalid("SERVER_SHUTDOWN_TIMEOUT", "must be positive")
+	}
+	if c.Server.DrainDelay < 0 || c.Server.DrainDelay >= c.Server.ShutdownTimeout {
+		invalid("SERVER_DRAIN_DELAY", "must be between 0 and SERVER_SHUTDOWN_TIMEOUT")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("LOG_LEVEL", "must be debug, info, warn or error")
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		invalid("LOG_FORMAT", "must be json or text")
+	}
+	if c.Log.AccessSampleRate < 0 || c.Log.AccessSampleRate > 1 {
+		invalid("LOG_ACCESS_SAMPLE_RATE", "must be between 0 and 1")
+	}
+
+	switch c.Telemetry.TracesExporter {
+	case "none", "console", "stdout", "otlp":
+	default:
+		invalid("OTEL_TRACES_EXPORTER", "must be none, console or otlp")
+	}
+	if c.Telemetry.TracesSampleRatio < 0 || c.Telemetry.TracesSampleRatio > 1 {
+		invalid("OTEL_TRACES_SAMPLER_ARG", "must be between 0 and 1")
+	}
+	for _, exporter := range c.Telemetry.MetricsExporters {
+		if exporter != "prometheus" && exporter != "otlp" && exporter != "none" {
+			invalid("OTEL_METRICS_EXPORTER", "unknown exporter %q (expected prometheus or otlp)", exporter)
+		}
+	}
+
+	if !c.Cosmos.InMemory() {
+		if u, err := url.Parse(c.Cosmos.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			invalid("COSMOS_ENDPOINT", "must be an https URL (ex: https://<account>.documents.azure.com:443/)")
+		}
+		required := map[string]string{
+			"COSMOS_DATABASE":                 c.Cosmos.Database,
+			"COSMOS_USERS_CONTAINER":          c.Cosmos.UsersContainer,
+			"COSMOS_API_KEYS_CONTAINER":       c.Cosmos.APIKeysContainer,
+			"COSMOS_REFRESH_TOKENS_CONTAINER": c.Cosmos.RefreshTokensContainer,
+			// Partagées par toutes les instances : une clé aléatoire n'est acceptable qu'en mémoire.
+			"CURSOR_SIGNING_KEY":     c.Pagination.CursorSigningKey.Value(),
+			"INVITATION_SIGNING_KEY": c.Invitation.SigningKey.Value(),
+		}
+		for _, key := range sortedKeys(required) {
+			if required[key] == "" {
+				invalid(key, "is required when COSMOS_ENDPOINT is set")
+			}
+		}
+	}
+
+	switch {
+	case c.Auth.JWKSURL != "" && c.Auth.JWKSFile != "":
+		invalid("AUTH_JWKS_URL", "cannot be combined with AUTH_JWKS_FILE")
+	case c.Auth.UsesJWKS():
+		if c.Auth.JWKSURL != "" {
+			if u, err := url.Parse(c.Auth.JWKSURL); err != nil || u.Scheme != "https" || u.Host == "" {
+				invalid("AUTH_JWKS_URL", "must be an https URL")
+			}
+		}
+		if len(c.Auth.Issuers) == 0 {
+			invalid("AUTH_ISSUERS", "is required with AUTH_JWKS_URL or AUTH_JWKS_FILE")
+		}
+		if c.Auth.Audience == "" {
+			invalid("AUTH_AUDIENCE", "is required with AUTH_JWKS_URL or AUTH_JWKS_FILE")
+		}
+	case c.Auth.JWTSecret == "":
+		invalid("JWT_SECRET", "is required unless AUTH_JWKS_URL or AUTH_JWKS_FILE is set")
+	}
+	if c.Auth.ClockSkew < 0 {
+		invalid("AUTH_CLOCK_SKEW", "cannot be negative")
+	}
+
+	if c.Invitation.TTL <= 0 {
+		invalid("INVITATION_TTL", "must be positive")
+	}
+
+	return errs
+}
+
+// FieldError décrit un réglage invalide, identifié par sa variable d'environnement.
+type FieldError struct {
+	Key     string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+// Report met en forme une erreur de Load pour la sortie d'erreur : une ligne par réglage.
+func Report(err error) string {
+	errs := []error{err}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		errs = joined.Unwrap()
+	}
+	report := "invalid configuration:"
+	for _, e := range errs {
+		report += "\n  - " + e.Error()
+	}
+	return report
+}
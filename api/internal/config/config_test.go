@@ -0,0 +1,136 @@
+package config_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/internal/config"
+)
+
+// setEnv isole le test des variables de la machine : seules celles fournies sont définies.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, key := range []string{
+		config.FileEnv, "COSMOS_ENDPOINT", "JWT_SECRET", "AUTH_JWKS_URL", "AUTH_JWKS_FILE", "AUTH_ISSUERS",
+		"AUTH_AUDIENCE", "FUNCTIONS_CUSTOMHANDLER_PORT", "LOG_LEVEL", "INVITATION_TTL", "METRICS_TOKEN",
+		"SERVER_DRAIN_DELAY", "SERVER_SHUTDOWN_TIMEOUT", "MODULES", "CURSOR_SIGNING_KEY", "INVITATION_SIGNING_KEY",
+	} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	setEnv(t, map[string]string{"JWT_SECRET": "dev-secret"})
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 25*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.True(t, cfg.Cosmos.InMemory())
+	assert.Equal(t, "TestDB", cfg.Cosmos.Database)
+	assert.Equal(t, "UsersContainer", cfg.Cosmos.UsersContainer)
+	assert.Equal(t, 7*24*time.Hour, cfg.Invitation.TTL)
+	assert.Equal(t, []string{"prometheus"}, cfg.Telemetry.MetricsExporters)
+	assert.Equal(t, "dev-secret", cfg.Auth.JWTSecret.Value())
+	assert.Empty(t, cfg.Modules.Enabled, "tous les modules disponibles")
+}
+
+func TestLoad_FileAndEnvPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"COSMOS_ENDPOINT": "https://erp.documents.azure.com:443/",
+		"COSMOS_DATABASE": "Prod",
+		"FUNCTIONS_CUSTOMHANDLER_PORT": 9000,
+		"AUTH_ISSUERS": ["https://login.microsoftonline.com/{tenantid}/v2.0", "test-api"],
+		"JWT_SECRET": "from-file",
+		"CURSOR_SIGNING_KEY": "cursor-key",
+		"INVITATION_SIGNING_KEY": "invitation-key"
+	}`), 0o600))
+	setEnv(t, map[string]string{config.FileEnv: path, "COSMOS_DATABASE": "FromEnv", "MODULES": "users, api-keys"})
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "https://erp.documents.azure.com:443/", cfg.Cosmos.Endpoint)
+	assert.Equal(t, "FromEnv", cfg.Cosmos.Database, "l'environnement l'emporte sur le fichier")
+	assert.Equal(t, 9000, cfg.Server.Port)
+	assert.Equal(t, []string{"https://login.microsoftonline.com/{tenantid}/v2.0", "test-api"}, cfg.Auth.Issuers)
+	assert.Equal(t, []string{"users", "api-keys"}, cfg.Modules.Enabled)
+}
+
+func TestLoad_AggregatedErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"COSMOS_DATABSE": "typo"}`), 0o600))
+	setEnv(t, map[string]string{
+		config.FileEnv:                 path,
+		"FUNCTIONS_CUSTOMHANDLER_PORT": "http",
+		"INVITATION_TTL":               "7 jours",
+		"LOG_LEVEL":                    "verbeux",
+		"COSMOS_ENDPOINT":              "erp.documents.azure.com",
+	})
+
+	// Erreurs de conversion et de validation sont rapportées ensemble, en une seule passe.
+	_, err := config.Load()
+	require.Error(t, err)
+	report := config.Report(err)
+	for _, expected := range []string{"FUNCTIONS_CUSTOMHANDLER_PORT", "INVITATION_TTL", "COSMOS_DATABSE", "LOG_LEVEL", "COSMOS_ENDPOINT", "JWT_SECRET"} {
+		assert.Contains(t, report, expected)
+	}
+	// Un réglage illisible n'est pas signalé une seconde fois par la validation.
+	assert.Equal(t, 1, strings.Count(report, "FUNCTIONS_CUSTOMHANDLER_PORT"), report)
+	assert.Equal(t, 1, strings.Count(report, "INVITATION_TTL"), report)
+
+	setEnv(t, map[string]string{"LOG_LEVEL": "verbeux", "COSMOS_ENDPOINT": "erp.documents.azure.com", "AUTH_JWKS_URL": "https://login/keys", "SERVER_DRAIN_DELAY": "30s"})
+	_, err = config.Load()
+	require.Error(t, err)
+	report = config.Report(err)
+	for _, expected := range []string{"LOG_LEVEL", "COSMOS_ENDPOINT", "AUTH_ISSUERS", "AUTH_AUDIENCE", "SERVER_DRAIN_DELAY"} {
+		assert.Contains(t, report, expected)
+	}
+}
+
+func TestLoad_SigningKeysRequiredWithCosmos(t *testing.T) {
+	// En mémoire, une clé aléatoire par processus suffit.
+	setEnv(t, map[string]string{"JWT_SECRET": "dev-secret"})
+	_, err := config.Load()
+	require.NoError(t, err)
+
+	// Avec Cosmos, plusieurs instances partagent les curseurs et les invitations.
+	setEnv(t, map[string]string{"JWT_SECRET": "dev-secret", "COSMOS_ENDPOINT": "https://erp.documents.azure.com:443/"})
+	_, err = config.Load()
+	require.Error(t, err)
+	report := config.Report(err)
+	assert.Contains(t, report, "CURSOR_SIGNING_KEY")
+	assert.Contains(t, report, "INVITATION_SIGNING_KEY")
+	assert.NotContains(t, report, "COSMOS_DATABASE", "les conteneurs ont une valeur par défaut")
+}
+
+func TestSecret_Redaction(t *testing.T) {
+	setEnv(t, map[string]string{"JWT_SECRET": "super-secret-value", "METRICS_TOKEN": "scrape-me"})
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	printed := []string{cfg.String(), fmt.Sprintf("%v", cfg), fmt.Sprintf("%+v", *cfg)}
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	printed = append(printed, string(data))
+
+	for _, out := range printed {
+		assert.NotContains(t, out, "super-secret-value")
+		assert.NotContains(t, out, "scrape-me")
+		assert.Contains(t, out, "[REDACTED]")
+	}
+}
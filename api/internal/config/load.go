@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileEnv est la variable désignant le fichier de configuration optionnel : un objet JSON
+// dont les clés sont les noms des variables d'environnement (ex : {"COSMOS_DATABASE": "Prod"}).
+const FileEnv = "CONFIG_FILE"
+
+// Load construit la configuration (défauts, puis fichier, puis environnement) et la valide.
+// L'erreur retournée regroupe tous les problèmes (voir Report).
+func Load() (*Config, error) {
+	file, err := readFile(os.Getenv(FileEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(key string) (string, bool) {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := file[key]
+		return v, ok
+	}
+
+	var cfg Config
+	known := map[string]bool{}
+	errs := load(reflect.ValueOf(&cfg).Elem(), lookup, known)
+
+	// Une clé inconnue dans le fichier est presque toujours une faute de frappe.
+	for _, key := range sortedKeys(file) {
+		if !known[key] {
+			errs = append(errs, &FieldError{Key: key, Message: "unknown setting in " + FileEnv})
+		}
+	}
+
+	// Tout est rapporté en une fois : conversion puis validation. Un réglage illisible n'est
+	// signalé qu'une fois (sa valeur zéro ferait aussi échouer la validation).
+	failed := map[string]bool{}
+	for _, err := range errs {
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			failed[fe.Key] = true
+		}
+	}
+	for _, fe := range cfg.validate() {
+		if !failed[fe.Key] {
+			errs = append(errs, fe)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &cfg, nil
+}
+
+// readFile lit le fichier de configuration. Les valeurs non textuelles (nombres, booléens,
+// listes) sont converties dans la même syntaxe que les variables d'environnement.
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", FileEnv, err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON in %s: %w", FileEnv, path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// load remplit récursivement les champs portant un tag env.
+func load(v reflect.Value, lookup func(string) (string, bool), known map[string]bool) []error {
+	var errs []error
+	t := v.Type()
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			errs = append(errs, load(value, lookup, known)...)
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		known[key] = true
+
+		raw, ok := lookup(key)
+		if !ok {
+			raw = field.Tag.Get("default")
+		}
+		if err := set(value, strings.TrimSpace(raw)); err != nil {
+			errs = append(errs, &FieldError{Key: key, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set convertit la valeur textuelle vers le type du champ.
+func set(v reflect.Value, raw string) error {
+	if raw == "" {
+		v.SetZero()
+		return nil
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (ex: 30s, 5m, 168h)", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"test-api/kit/logger"
+)
+
+// flushTimeout borne l'exécution des fonctions d'arrêt (envoi des dernières traces et métriques),
+// qui disposent de leur propre délai : elles doivent s'exécuter même si le drainage a expiré.
+const flushTimeout = 5 * time.Second
+
+// ShutdownConfig règle l'arrêt gracieux.
+type ShutdownConfig struct {
+	// DrainDelay laisse aux répartiteurs de charge le temps de constater l'échec de la sonde
+	// de disponibilité avant que le serveur ne cesse d'accepter des connexions.
+	DrainDelay time.Duration
+	// Timeout borne l'attente des requêtes en cours ; au-delà, les connexions sont coupées.
+	Timeout time.Duration
+}
+
+// ErrDraining est le résultat de la vérification de disponibilité pendant l'arrêt.
+var ErrDraining = errors.New("server: shutting down")
+
+// Lifecycle suit le cycle de vie du processus : en service, puis en cours d'arrêt (drainage).
+// Enregistré comme vérification de santé, il fait échouer /health/ready pendant le drainage.
+type Lifecycle struct {
+	draining atomic.Bool
+
+	mu    sync.Mutex
+	hooks []func(context.Context) error
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Draining indique que l'arrêt est en cours : l'instance ne doit plus recevoir de trafic.
+func (l *Lifecycle) Draining() bool {
+	return l.draining.Load()
+}
+
+// Check implémente health.Checker : en échec dès que l'arrêt a commencé.
+func (l *Lifecycle) Check(context.Context) error {
+	if l.Draining() {
+		return ErrDraining
+	}
+	return nil
+}
+
+// OnShutdown enregistre une fonction appelée une fois les requêtes drainées (vidage des
+// exporteurs, arrêt des tâches de fond...). Les fonctions sont appelées dans l'ordre inverse
+// de leur enregistrement, comme des defer.
+func (l *Lifecycle) OnShutdown(hook func(context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Run sert srv sur ln jusqu'à l'annulation de ctx (SIGTERM, SIGINT), puis arrête proprement :
+// sonde de disponibilité en échec, attente de DrainDelay, fermeture de l'écoute, attente des
+// requêtes en cours (au plus Timeout) et enfin appel des fonctions d'arrêt.
+func (l *Lifecycle) Run(ctx context.Context, srv *http.Server, ln net.Listener, cfg ShutdownConfig) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// Le serveur s'est arrêté seul : erreur fatale (l'arrêt normal passe par ctx).
+		return errors.Join(err, l.runHooks())
+	case <-ctx.Done():
+	}
+
+	logger.Info(context.Background(), "Arrêt demandé : drainage des requêtes en cours",
+		"drainDelay", cfg.DrainDelay, "timeout", cfg.Timeout)
+	l.draining.Store(true)
+
+	deadline := time.Now().Add(cfg.Timeout)
+	if cfg.DrainDelay > 0 {
+		time.Sleep(min(cfg.DrainDelay, time.Until(deadline)))
+	}
+
+	shutdownCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Warn(context.Background(), "Délai d'arrêt dépassé : connexions restantes coupées")
+		err = errors.Join(err, srv.Close())
+	}
+
+	err = errors.Join(err, l.runHooks())
+	if err == nil {
+		logger.Info(context.Background(), "Arrêt terminé")
+	}
+	return err
+}
+
+// runHooks appelle les fonctions d'arrêt, les plus récentes d'abord.
+func (l *Lifecycle) runHooks() error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.hooks = nil
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		errs = append(errs, hooks[i](ctx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,102 @@
+package server_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/internal/server"
+)
+
+func TestLifecycle_GracefulShutdown(t *testing.T) {
+	lifecycle := server.NewLifecycle()
+	var calls []string
+	lifecycle.OnShutdown(func(context.Context) error { calls = append(calls, "traces"); return nil })
+	lifecycle.OnShutdown(func(context.Context) error { calls = append(calls, "métriques"); return nil })
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lent", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("terminé"))
+	})
+
+	ln := newListener(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- lifecycle.Run(ctx, &http.Server{Handler: mux}, ln, server.ShutdownConfig{Timeout: 5 * time.Second})
+	}()
+
+	// Une requête en cours au moment du signal d'arrêt...
+	responses := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/lent")
+		if err == nil {
+			responses <- res
+		}
+		close(responses)
+	}()
+	<-started
+	cancel()
+
+	// ... la disponibilité tombe, mais la requête est menée à son terme.
+	require.Eventually(t, lifecycle.Draining, time.Second, 10*time.Millisecond)
+	close(release)
+	res, ok := <-responses
+	require.True(t, ok, "la requête en cours ne doit pas être coupée")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"métriques", "traces"}, calls, "fonctions d'arrêt appelées comme des defer")
+
+	// L'écoute est fermée : plus aucune connexion acceptée.
+	_, err := net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestLifecycle_ShutdownTimeout(t *testing.T) {
+	lifecycle := server.NewLifecycle()
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bloque", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+
+	ln := newListener(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- lifecycle.Run(ctx, &http.Server{Handler: mux}, ln, server.ShutdownConfig{Timeout: 100 * time.Millisecond})
+	}()
+	go func() {
+		if res, err := http.Get("http://" + ln.Addr().String() + "/bloque"); err == nil {
+			res.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("l'arrêt doit couper les connexions restantes une fois le délai dépassé")
+	}
+}
+
+func newListener(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return ln
+}
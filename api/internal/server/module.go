@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"test-api/kit/health"
+	"test-api/kit/logger"
+	"test-api/kit/openapi"
+)
+
+// =================================================================================
+// Modules métier
+// =================================================================================
+
+// Module est un domaine métier du monolithe modulaire (voir doc/01_ADR Structure Globale.md).
+// Chaque domaine de internal/ expose le sien ; main les construit et le routeur les monte
+// sans les connaître : ajouter un domaine ne modifie ni le routeur ni sa signature.
+type Module interface {
+	// Name identifie le module (logs, dépendances), ex : "users".
+	Name() string
+	// MountPath est le préfixe de ses routes authentifiées sous /api, ex : "/users".
+	// Vide si le module n'a que des routes publiques.
+	MountPath() string
+	// Dependencies liste les modules dont il a besoin : leur absence fait échouer le démarrage.
+	// Les dépendances entre domaines restent ainsi explicites, et sans cycle.
+	Dependencies() []string
+	// RegisterRoutes monte les routes authentifiées (le Principal est disponible).
+	RegisterRoutes(r chi.Router)
+}
+
+// PublicRoutes est implémenté par les modules qui exposent des routes sans authentification,
+// montées sous /api/auth (connexion, acceptation d'une invitation...).
+type PublicRoutes interface {
+	RegisterPublicRoutes(r chi.Router)
+}
+
+// Documented est implémenté par les modules qui décrivent leurs routes dans le document OpenAPI
+// (/openapi.json). routes est relatif au préfixe de montage (/api + MountPath), public à /api/auth.
+// Toute route montée doit être décrite : le démarrage échoue sinon (voir openapi.Spec.Build).
+type Documented interface {
+	DescribeRoutes(routes, public *openapi.Spec)
+}
+
+// HealthChecks est implémenté par les modules qui ont leurs propres vérifications de santé.
+type HealthChecks interface {
+	RegisterHealthChecks(checks *health.Registry)
+}
+
+// BackgroundJobs est implémenté par les modules qui ont des tâches de fond.
+type BackgroundJobs interface {
+	Jobs() []Job
+}
+
+// Job est une tâche de fond : Run tourne jusqu'à l'annulation de son contexte, à l'arrêt du serveur.
+type Job struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// =================================================================================
+// Catalogue
+// =================================================================================
+
+// ModuleFactory construit un module du catalogue de l'application, s'il est activé.
+type ModuleFactory struct {
+	Name string
+	New  func() (Module, error)
+}
+
+// BuildModules construit les modules activés (enabled, config MODULES), dans l'ordre du catalogue.
+// Un nom absent du catalogue, ou un module qui ne peut pas être construit dans cette
+// configuration, fait échouer le démarrage.
+func BuildModules(catalog []ModuleFactory, enabled []string) ([]Module, error) {
+	available := make([]string, 0, len(catalog))
+	for _, f := range catalog {
+		available = append(available, f.Name)
+	}
+
+	var errs []error
+	for _, name := range enabled {
+		if !slices.Contains(available, name) {
+			errs = append(errs, fmt.Errorf("unknown module %q (available: %s)", name, strings.Join(available, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var modules []Module
+	for _, f := range catalog {
+		if !slices.Contains(enabled, f.Name) {
+			continue
+		}
+		m, err := f.New()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("module %q: %w", f.Name, err))
+			continue
+		}
+		modules = append(modules, m)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return modules, nil
+}
+
+// =================================================================================
+// Registre
+// =================================================================================
+
+// Registry est l'ensemble validé des modules de l'application, dans l'ordre de leurs dépendances.
+type Registry struct {
+	modules []Module
+}
+
+// NewRegistry vérifie les modules (noms et préfixes uniques, dépendances présentes et sans
+// cycle) et les ordonne : un module vient toujours après ses dépendances.
+func NewRegistry(modules ...Module) (*Registry, error) {
+	byName := make(map[string]Module, len(modules))
+	mountedBy := make(map[string]string, len(modules))
+	var errs []error
+	for _, m := range modules {
+		if _, exists := byName[m.Name()]; exists {
+			errs = append(errs, fmt.Errorf("module %q registered twice", m.Name()))
+			continue
+		}
+		byName[m.Name()] = m
+		if path := m.MountPath(); path != "" {
+			if other, exists := mountedBy[path]; exists {
+				errs = append(errs, fmt.Errorf("modules %q and %q share mount path %q", other, m.Name(), path))
+			}
+			mountedBy[path] = m.Name()
+		}
+	}
+	for _, m := range modules {
+		for _, dep := range m.Dependencies() {
+			if _, exists := byName[dep]; !exists {
+				errs = append(errs, fmt.Errorf("module %q depends on missing module %q", m.Name(), dep))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// Tri topologique (parcours en profondeur), stable : l'ordre d'enregistrement est conservé
+	// entre modules indépendants.
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(modules))
+	ordered := make([]Module, 0, len(modules))
+	var visit func(m Module, path []string) error
+	visit = func(m Module, path []string) error {
+		switch state[m.Name()] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("module dependency cycle: %s", strings.Join(append(path, m.Name()), " -> "))
+		}
+		state[m.Name()] = visiting
+		for _, dep := range m.Dependencies() {
+			if err := visit(byName[dep], append(path, m.Name())); err != nil {
+				return err
+			}
+		}
+		state[m.Name()] = visited
+		ordered = append(ordered, m)
+		return nil
+	}
+	for _, m := range modules {
+		if err := visit(m, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &Registry{modules: ordered}, nil
+}
+
+// Modules retourne les modules, dépendances d'abord.
+func (r *Registry) Modules() []Module {
+	return r.modules
+}
+
+// RegisterHealthChecks ajoute les vérifications des modules à checks.
+func (r *Registry) RegisterHealthChecks(checks *health.Registry) {
+	for _, m := range r.modules {
+		if hc, ok := m.(HealthChecks); ok {
+			hc.RegisterHealthChecks(checks)
+		}
+	}
+}
+
+// StartJobs lance les tâches de fond des modules. Elles sont annulées à l'arrêt du serveur,
+// qui attend leur fin (dans le délai des fonctions d'arrêt) avant de vider traces et métriques.
+func (r *Registry) StartJobs(lifecycle *Lifecycle) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, m := range r.modules {
+		bj, ok := m.(BackgroundJobs)
+		if !ok {
+			continue
+		}
+		for _, job := range bj.Jobs() {
+			jobCtx := logger.WithAttrs(ctx, "module", m.Name(), "job", job.Name)
+			wg.Go(func() {
+				if err := job.Run(jobCtx); err != nil && !errors.Is(err, context.Canceled) {
+					logger.Error(jobCtx, "Tâche de fond arrêtée en erreur", "error", err)
+				}
+			})
+		}
+	}
+
+	lifecycle.OnShutdown(func(shutdownCtx context.Context) error {
+		cancel()
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return fmt.Errorf("background jobs still running: %w", shutdownCtx.Err())
+		}
+	})
+}
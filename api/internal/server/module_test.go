@@ -0,0 +1,213 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/internal/server"
+	"test-api/kit/auth"
+	"test-api/kit/health"
+	"test-api/kit/openapi"
+)
+
+// fakeModule est un domaine minimal : GET {MountPath}/ (authentifiée) et, si public,
+// GET /auth/{name} (publique).
+type fakeModule struct {
+	name   string
+	path   string
+	deps   []string
+	public bool
+}
+
+func (m fakeModule) Name() string           { return m.name }
+func (m fakeModule) MountPath() string      { return m.path }
+func (m fakeModule) Dependencies() []string { return m.deps }
+
+func (m fakeModule) RegisterRoutes(r chi.Router) {
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(m.name)) })
+}
+
+func (m fakeModule) DescribeRoutes(routes, _ *openapi.Spec) {
+	if m.path != "" {
+		routes.Add(http.MethodGet, "/", openapi.Operation{OperationID: "list" + m.name})
+	}
+}
+
+type publicModule struct{ fakeModule }
+
+func (m publicModule) RegisterPublicRoutes(r chi.Router) {
+	r.Get("/"+m.name, func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func (m publicModule) DescribeRoutes(routes, public *openapi.Spec) {
+	m.fakeModule.DescribeRoutes(routes, public)
+	public.Add(http.MethodGet, "/"+m.name, openapi.Operation{OperationID: "public" + m.name})
+}
+
+func names(modules []server.Module) []string {
+	var out []string
+	for _, m := range modules {
+		out = append(out, m.Name())
+	}
+	return out
+}
+
+func TestNewRegistry(t *testing.T) {
+	t.Run("Dépendances d'abord", func(t *testing.T) {
+		registry, err := server.NewRegistry(
+			fakeModule{name: "invoices", path: "/invoices", deps: []string{"customers", "products"}},
+			fakeModule{name: "products", path: "/products"},
+			fakeModule{name: "customers", path: "/customers", deps: []string{"users"}},
+			fakeModule{name: "users", path: "/users"},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users", "customers", "products", "invoices"}, names(registry.Modules()))
+	})
+
+	tests := []struct {
+		name     string
+		modules  []server.Module
+		expected string
+	}{
+		{"Nom en double", []server.Module{fakeModule{name: "users", path: "/users"}, fakeModule{name: "users", path: "/members"}}, `module "users" registered twice`},
+		{"Préfixe en double", []server.Module{fakeModule{name: "users", path: "/users"}, fakeModule{name: "members", path: "/users"}}, `share mount path "/users"`},
+		{"Dépendance absente", []server.Module{fakeModule{name: "sessions", deps: []string{"users"}}}, `depends on missing module "users"`},
+		{"Cycle", []server.Module{fakeModule{name: "a", deps: []string{"b"}}, fakeModule{name: "b", deps: []string{"a"}}}, "cycle: a -> b -> a"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := server.NewRegistry(tc.modules...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
+
+func TestBuildModules(t *testing.T) {
+	built := map[string]int{}
+	factory := func(name string, err error) server.ModuleFactory {
+		return server.ModuleFactory{Name: name, New: func() (server.Module, error) {
+			built[name]++
+			if err != nil {
+				return nil, err
+			}
+			return fakeModule{name: name, path: "/" + name}, nil
+		}}
+	}
+	catalog := []server.ModuleFactory{
+		factory("users", nil),
+		factory("api-keys", nil),
+		factory("sessions", errors.New("local login requires JWT_SECRET")),
+	}
+
+	t.Run("Seuls les modules activés sont construits, dans l'ordre du catalogue", func(t *testing.T) {
+		clear(built)
+		modules, err := server.BuildModules(catalog, []string{"api-keys", "users"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users", "api-keys"}, names(modules))
+		assert.Zero(t, built["sessions"])
+	})
+
+	t.Run("Module inconnu", func(t *testing.T) {
+		clear(built)
+		_, err := server.BuildModules(catalog, []string{"users", "products"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown module "products"`)
+		assert.Empty(t, built, "rien n'est construit si la liste est invalide")
+	})
+
+	t.Run("Module indisponible dans cette configuration", func(t *testing.T) {
+		_, err := server.BuildModules(catalog, []string{"users", "sessions"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "JWT_SECRET")
+	})
+}
+
+func TestNewRouter_MountsModules(t *testing.T) {
+	registry, err := server.NewRegistry(
+		fakeModule{name: "users", path: "/users"},
+		publicModule{fakeModule{name: "sessions", deps: []string{"users"}}},
+	)
+	require.NoError(t, err)
+	authenticator, err := auth.NewHMACAuthenticator([]byte("test-secret"), auth.WithIssuers("test-api"))
+	require.NoError(t, err)
+	router, err := server.NewRouter(registry, authenticator, nil, health.NewRegistry())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		target       string
+		expectedCode int
+	}{
+		{"Route publique", "/api/auth/sessions", http.StatusOK},
+		{"Route authentifiée sans jeton", "/api/users/", http.StatusUnauthorized},
+		{"Module sans routes authentifiées", "/api/sessions/", http.StatusNotFound},
+		{"Sonde", "/health/ready", http.StatusOK},
+		{"Document OpenAPI", "/openapi.json", http.StatusOK},
+		{"Interface de documentation", "/docs/", http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.target, nil))
+			assert.Equal(t, tc.expectedCode, rr.Code)
+		})
+	}
+}
+
+// undocumentedModule monte une route sans la décrire : le routeur doit refuser de démarrer.
+type undocumentedModule struct{ fakeModule }
+
+func (undocumentedModule) DescribeRoutes(_, _ *openapi.Spec) {}
+
+func TestNewRouter_UndocumentedRoute(t *testing.T) {
+	registry, err := server.NewRegistry(undocumentedModule{fakeModule{name: "users", path: "/users"}})
+	require.NoError(t, err)
+	authenticator, err := auth.NewHMACAuthenticator([]byte("test-secret"), auth.WithIssuers("test-api"))
+	require.NoError(t, err)
+
+	_, err = server.NewRouter(registry, authenticator, nil, health.NewRegistry())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "route GET /api/users is not documented")
+}
+
+type jobModule struct {
+	fakeModule
+	stopped chan struct{}
+}
+
+func (m jobModule) Jobs() []server.Job {
+	return []server.Job{{Name: "purge", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		close(m.stopped)
+		return ctx.Err()
+	}}}
+}
+
+func TestRegistry_StartJobs(t *testing.T) {
+	module := jobModule{fakeModule: fakeModule{name: "users", path: "/users"}, stopped: make(chan struct{})}
+	registry, err := server.NewRegistry(module)
+	require.NoError(t, err)
+
+	lifecycle := server.NewLifecycle()
+	registry.StartJobs(lifecycle)
+
+	// L'arrêt du serveur annule les tâches de fond et attend leur fin.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ln := newListener(t)
+	require.NoError(t, lifecycle.Run(ctx, &http.Server{}, ln, server.ShutdownConfig{Timeout: time.Second}))
+	select {
+	case <-module.stopped:
+	default:
+		t.Fatal("la tâche de fond doit être arrêtée avant la fin de Run")
+	}
+}
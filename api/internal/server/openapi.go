@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+
+	"test-api/kit/api"
+	"test-api/kit/health"
+	"test-api/kit/openapi"
+)
+
+// Emplacements du document OpenAPI et de son interface de consultation.
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs"
+)
+
+// apiInfo identifie l'API dans le document ; Version suit le contrat HTTP, pas les déploiements.
+var apiInfo = openapi.Info{
+	Title:       "test-api",
+	Version:     "1.0.0",
+	Description: "API de l'ERP. Les erreurs suivent la RFC 9457 (application/problem+json).",
+}
+
+// Schémas de sécurité du document.
+const (
+	securityBearer  = "bearerAuth"
+	securityAPIKey  = "apiKey"
+	securityMetrics = "metricsToken"
+)
+
+// newSpec prépare le document : schémas de sécurité et routes techniques (sondes, métriques,
+// documentation). Les routes métier sont décrites par les modules (voir Documented).
+func newSpec(withMetrics bool) *openapi.Spec {
+	spec := openapi.New(apiInfo, api.Problem{}, api.ProblemContentType)
+	spec.SecurityScheme(securityBearer, openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Jeton d'accès Entra ID, ou émis par POST /api/auth/login.",
+	}, true)
+	spec.SecurityScheme(securityAPIKey, openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          openapi.InHeader,
+		Name:        "X-API-Key",
+		Description: "Clé d'API (intégrations machine à machine), aussi acceptée en \"Authorization: ApiKey <clé>\".",
+	}, true)
+
+	spec.Tag("technique", "Sondes, métriques et documentation.")
+	technical := spec.Public("").Tagged("technique")
+
+	probe := func(summary string) openapi.Operation {
+		return openapi.Operation{
+			Summary: summary,
+			Responses: map[string]*openapi.Response{
+				"200": technical.JSON("Instance opérationnelle (éventuellement dégradée)", health.Report{}),
+				"503": technical.JSON("Instance indisponible", health.Report{}),
+			},
+		}
+	}
+	live := probe("Sonde de vivacité")
+	live.OperationID = "getLiveness"
+	technical.Add(http.MethodGet, "/health/live", live)
+	ready := probe("Sonde de disponibilité (dépendances critiques, arrêt en cours)")
+	ready.OperationID = "getReadiness"
+	technical.Add(http.MethodGet, "/health/ready", ready)
+	legacy := probe("Alias de /health/ready")
+	legacy.OperationID = "getHealth"
+	technical.Add(http.MethodGet, "/health", legacy)
+
+	if withMetrics {
+		spec.SecurityScheme(securityMetrics, openapi.SecurityScheme{
+			Type:        "http",
+			Scheme:      "bearer",
+			Description: "Jeton du collecteur de métriques (METRICS_TOKEN).",
+		}, false)
+		technical.Add(http.MethodGet, "/metrics", openapi.Operation{
+			OperationID: "getMetrics",
+			Summary:     "Métriques au format d'exposition Prometheus",
+			Security:    &[]openapi.SecurityRequirement{{securityMetrics: {}}},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "Métriques", Content: map[string]openapi.MediaType{"text/plain": {Schema: &openapi.Schema{Type: "string"}}}},
+			},
+		})
+	}
+
+	technical.Add(http.MethodGet, OpenAPIPath, openapi.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "Ce document (OpenAPI 3.1)",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Document OpenAPI", Content: map[string]openapi.MediaType{"application/json": {Schema: &openapi.Schema{Type: "object"}}}},
+		},
+	})
+	spec.Ignore(DocsPath)
+	return spec
+}
@@ -0,0 +1,63 @@
+package server_test
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/internal/apikey"
+	"test-api/internal/server"
+	"test-api/internal/session"
+	"test-api/internal/user"
+	"test-api/kit/auth"
+	"test-api/kit/health"
+)
+
+// go test ./internal/server -run TestOpenAPI_Golden -update régénère le document de référence
+// après une évolution volontaire du contrat HTTP (à relire dans le diff avant de commiter).
+var update = flag.Bool("update", false, "met à jour testdata/openapi.golden.json")
+
+func TestOpenAPI_Golden(t *testing.T) {
+	// Les services ne sont pas appelés : seules les routes et leurs descriptions comptent.
+	registry, err := server.NewRegistry(user.NewModule(nil, nil), apikey.NewModule(nil), session.NewModule(nil))
+	require.NoError(t, err)
+	authenticator, err := auth.NewHMACAuthenticator([]byte("test-secret"), auth.WithIssuers("test-api"))
+	require.NoError(t, err)
+	router, err := server.NewRouter(registry, authenticator, http.NotFoundHandler(), health.NewRegistry())
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, server.OpenAPIPath, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	golden := filepath.Join("testdata", "openapi.golden.json")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, rr.Body.Bytes(), 0o644))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err, "document de référence absent : lancer le test avec -update")
+	assert.JSONEq(t, string(expected), rr.Body.String(),
+		"le document OpenAPI a changé : si c'est voulu, relancer avec -update et relire le diff")
+
+	// Quelques garanties indépendantes du fichier de référence.
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Contains(t, doc.Components.Schemas, "User")
+	assert.NotContains(t, doc.Components.Schemas["User"].Properties, "passwordHash", "les secrets ne sont pas exposés")
+}
@@ -1,78 +1,108 @@
 package server
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
-	"test-api/internal/user"
+	"test-api/kit/auth"
+	"test-api/kit/health"
 	"test-api/kit/logger"
+	"test-api/kit/metrics"
+	"test-api/kit/openapi"
+	"test-api/kit/tracing"
 )
 
-func NewRouter(userHandler *user.Handler) http.Handler {
+// NewRouter monte les routes techniques (sondes, métriques, documentation) et celles des modules
+// métier : routes publiques sous /api/auth, routes authentifiées sous /api + MountPath.
+// Il échoue si le document OpenAPI ne décrit pas exactement les routes montées.
+func NewRouter(modules *Registry, authenticator *auth.Authenticator, metricsHandler http.Handler, checks *health.Registry) (http.Handler, error) {
 	r := chi.NewRouter()
 
 	// =========================================================================
 	// Middlewares Globaux
 	// =========================================================================
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Recoverer)
+	// Span serveur OpenTelemetry (contexte W3C entrant repris, sinon nouvelle trace).
+	r.Use(tracing.Middleware)
+	// Compteurs et latences par route et statut (exposés sur /metrics).
+	r.Use(metrics.Middleware)
 
 	// 2. On utilise le Middleware de notre nouveau package "logger"
 	// (Remplace "middleware.Logger" de Chi qui fait des logs texte moches)
 	r.Use(logger.Middleware)
+	// Une ligne par requête (route, statut, durée, taille), au niveau WARN/ERROR pour les erreurs.
+	// Placé avant Recoverer pour journaliser aussi les 500 dues à un panic.
+	r.Use(logger.AccessLog())
+	r.Use(middleware.Recoverer)
 
 	// =========================================================================
 	// Routes de base
 	// =========================================================================
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		// 3. UTILISATION PROPRE : On appelle le package logger importé
-		logger.Info(r.Context(), "Health check déclenché : tout va bien")
-
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+	// Sondes de la plateforme : vivacité (le processus répond) et disponibilité (dépendances
+	// critiques joignables, instance hors drainage). /health est conservé pour les moniteurs existants.
+	r.Method(http.MethodGet, "/health/live", checks.LiveHandler())
+	r.Method(http.MethodGet, "/health/ready", checks.ReadyHandler())
+	r.Method(http.MethodGet, "/health", checks.ReadyHandler())
+
+	// Métriques Prometheus, réservées au collecteur (jeton dédié) ; absent sans METRICS_TOKEN.
+	if metricsHandler != nil {
+		r.Method(http.MethodGet, "/metrics", metricsHandler)
+	}
+
+	// Document OpenAPI (construit une fois toutes les routes montées) et interface Swagger UI.
+	var specHandler http.Handler
+	r.Get(OpenAPIPath, func(w http.ResponseWriter, req *http.Request) {
+		specHandler.ServeHTTP(w, req)
 	})
+	r.Mount(DocsPath, openapi.UIHandler(apiInfo.Title, OpenAPIPath, DocsPath))
 
 	// =========================================================================
 	// Montage des routes API des différents domaines (/api/...)
 	// =========================================================================
 	// On groupe toutes les routes API sous le préfixe "/api"
 	r.Route("/api", func(apiRouter chi.Router) {
-		apiRouter.Use(devTenantMiddleware)
-
-		apiRouter.Route("/users", func(userRouter chi.Router) {
-			userHandler.RegisterRoutes(userRouter)
+		// Routes publiques : connexion locale, acceptation des invitations...
+		apiRouter.Route("/auth", func(authRouter chi.Router) {
+			for _, m := range modules.Modules() {
+				if public, ok := m.(PublicRoutes); ok {
+					public.RegisterPublicRoutes(authRouter)
+				}
+			}
 		})
 
-		// --- (Futur) Domaine PRODUCT ---
-		/*
-			apiRouter.Route("/products", func(productRouter chi.Router) {
-				productHandler.RegisterRoutes(productRouter)
-			})
-		*/
+		apiRouter.Group(func(protected chi.Router) {
+			// Toutes les autres routes API exigent un jeton valide ou une clé d'API : le Principal
+			// (tenant, utilisateur, rôles) est ensuite disponible via auth.PrincipalFrom / auth.TenantID.
+			protected.Use(authenticator.Middleware)
+
+			for _, m := range modules.Modules() {
+				if m.MountPath() == "" {
+					continue
+				}
+				protected.Route(m.MountPath(), m.RegisterRoutes)
+			}
+		})
 	})
 
-	return r
-}
-
-// --- AJOUT TEMPORAIRE : Middleware pour simuler un tenant ---
-// Ce middleware injecte un ID "en dur" pour le développement.
-// À RETIRER une fois le vrai middleware d'auth implémenté dans kit/auth/.
-func devTenantMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 1. La valeur en dur que tu veux utiliser pour tes tests actuels
-		hardcodedTenantID := "tenant-admin"
-
-		// 2. On crée un nouveau contexte dérivé de celui de la requête,
-		// en y ajoutant la valeur avec la clé définie dans kit/contextkeys
-		ctx := context.WithValue(r.Context(), user.TenantIDContextKey, hardcodedTenantID)
-
-		// 3. Créer une nouvelle requête avec ce nouveau contexte
-		rWithCtx := r.WithContext(ctx)
-
-		// 4. Passer la main au handler suivant avec la requête modifiée
-		next.ServeHTTP(w, rWithCtx)
-	})
+	// =========================================================================
+	// Documentation
+	// =========================================================================
+	spec := newSpec(metricsHandler != nil)
+	for _, m := range modules.Modules() {
+		if documented, ok := m.(Documented); ok {
+			spec.Tag(m.Name(), "")
+			documented.DescribeRoutes(spec.Group("/api"+m.MountPath()).Tagged(m.Name()), spec.Public("/api/auth").Tagged(m.Name()))
+		}
+	}
+	doc, err := spec.Build(r)
+	if err != nil {
+		return nil, err
+	}
+	if specHandler, err = openapi.Handler(doc); err != nil {
+		return nil, err
+	}
+
+	return r, nil
 }
@@ -0,0 +1,94 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+)
+
+// Handler gère les requêtes HTTP d'authentification locale.
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// RegisterRoutes définit les points d'entrée HTTP pour le module Session.
+// Ces routes sont publiques : elles doivent être montées hors du middleware d'authentification.
+//
+// POST /auth/login : Connexion par email et mot de passe
+// POST /auth/refresh : Rotation du jeton de rafraîchissement
+// POST /auth/logout : Révocation de la session
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Post("/login", h.Login)
+	r.Post("/refresh", h.Refresh)
+	r.Post("/logout", h.Logout)
+}
+
+// =================================================================================
+// Handlers HTTP
+// =================================================================================
+
+// Login POST /auth/login
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var input LoginInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
+		return
+	}
+	defer r.Body.Close()
+
+	tokens, err := h.service.Login(r.Context(), input)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+	respondWithTokens(w, r, tokens)
+}
+
+// Refresh POST /auth/refresh
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var input RefreshInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
+		return
+	}
+	defer r.Body.Close()
+
+	tokens, err := h.service.Refresh(r.Context(), input.RefreshToken)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+	respondWithTokens(w, r, tokens)
+}
+
+// Logout POST /auth/logout
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	var input RefreshInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.service.Logout(r.Context(), input.RefreshToken); err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondWithTokens envoie des jetons : la réponse ne doit être conservée par aucun cache (RFC 6749 §5.1).
+func respondWithTokens(w http.ResponseWriter, r *http.Request, tokens *Tokens) {
+	w.Header().Set("Cache-Control", "no-store")
+	api.RespondWithJSON(w, r, http.StatusOK, tokens)
+}
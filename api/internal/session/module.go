@@ -0,0 +1,29 @@
+package session
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"test-api/internal/user"
+)
+
+// ModuleName identifie le domaine Session auprès des autres modules (server.Module).
+const ModuleName = "sessions"
+
+// Module expose la connexion locale au serveur. Toutes ses routes sont publiques (/auth/login...) :
+// il n'a pas de routes authentifiées.
+type Module struct {
+	handler *Handler
+}
+
+func NewModule(s Service) *Module {
+	return &Module{handler: NewHandler(s)}
+}
+
+func (m *Module) Name() string      { return ModuleName }
+func (m *Module) MountPath() string { return "" }
+
+// Dependencies : les identifiants sont vérifiés par le domaine User.
+func (m *Module) Dependencies() []string { return []string{user.ModuleName} }
+
+func (m *Module) RegisterRoutes(chi.Router)         {}
+func (m *Module) RegisterPublicRoutes(r chi.Router) { m.handler.RegisterRoutes(r) }
@@ -0,0 +1,30 @@
+package session
+
+import (
+	"net/http"
+
+	"test-api/kit/openapi"
+)
+
+// DescribeRoutes décrit les routes du module dans le document OpenAPI (voir Handler.RegisterRoutes) :
+// toutes publiques, relatives à /api/auth.
+func (m *Module) DescribeRoutes(_, public *openapi.Spec) {
+	public.Add(http.MethodPost, "/login", openapi.Operation{
+		OperationID: "login",
+		Summary:     "Connexion par email et mot de passe",
+		RequestBody: public.Body("application/json", LoginInput{}),
+		Responses:   map[string]*openapi.Response{"200": public.JSON("Jetons de la session", Tokens{})},
+	})
+	public.Add(http.MethodPost, "/refresh", openapi.Operation{
+		OperationID: "refreshSession",
+		Summary:     "Échange le jeton de rafraîchissement contre de nouveaux jetons (rotation)",
+		RequestBody: public.Body("application/json", RefreshInput{}),
+		Responses:   map[string]*openapi.Response{"200": public.JSON("Nouveaux jetons", Tokens{})},
+	})
+	public.Add(http.MethodPost, "/logout", openapi.Operation{
+		OperationID: "logout",
+		Summary:     "Révoque la session",
+		RequestBody: public.Body("application/json", RefreshInput{}),
+		Responses:   map[string]*openapi.Response{"204": openapi.NoContent("Session révoquée")},
+	})
+}
@@ -0,0 +1,234 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-api/internal/user"
+	"test-api/kit/apperr"
+	"test-api/kit/auth"
+	"test-api/kit/database"
+	"test-api/kit/logger"
+)
+
+// DefaultRefreshTokenTTL est la durée d'une session sans activité.
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// Préfixe des jetons de rafraîchissement (auth.OpaqueToken) : rt_<tenant en base64url>.<id>.<secret>.
+const tokenPrefix = "rt_"
+
+// reuseDetectionWindow prolonge la conservation des jetons expirés pour détecter leur réutilisation.
+const reuseDetectionWindow = 24 * time.Hour
+
+// -- Définition des erreurs métier --
+
+var ErrInvalidRefreshToken = apperr.New(apperr.ErrUnauthorized, "invalid or expired refresh token")
+
+type serviceImpl struct {
+	repo       database.Repository[RefreshToken]
+	users      user.Service
+	issuer     *auth.TokenIssuer
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// ServiceOption configure le service à la construction.
+type ServiceOption func(*serviceImpl)
+
+// WithRefreshTokenTTL définit la durée de vie des jetons de rafraîchissement.
+func WithRefreshTokenTTL(d time.Duration) ServiceOption {
+	return func(s *serviceImpl) {
+		s.refreshTTL = d
+	}
+}
+
+func NewService(repo database.Repository[RefreshToken], users user.Service, issuer *auth.TokenIssuer, opts ...ServiceOption) Service {
+	s := &serviceImpl{
+		repo:       repo,
+		users:      users,
+		issuer:     issuer,
+		refreshTTL: DefaultRefreshTokenTTL,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Login vérifie les identifiants et ouvre une nouvelle famille de jetons.
+func (s *serviceImpl) Login(ctx context.Context, input LoginInput) (*Tokens, error) {
+	var errs []error
+	if strings.TrimSpace(input.TenantID) == "" {
+		errs = append(errs, apperr.InvalidField("tenantID", "cannot be empty"))
+	}
+	if strings.TrimSpace(input.Email) == "" {
+		errs = append(errs, apperr.InvalidField("email", "cannot be empty"))
+	}
+	if input.Password == "" {
+		errs = append(errs, apperr.InvalidField("password", "cannot be empty"))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	u, err := s.users.VerifyCredentials(ctx, input.TenantID, input.Email, input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(ctx, u, uuid.NewString(), uuid.NewString())
+}
+
+// Refresh applique la rotation : le jeton présenté est consommé et remplacé.
+func (s *serviceImpl) Refresh(ctx context.Context, raw string) (*Tokens, error) {
+	current, err := s.lookup(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+		return nil, fmt.Errorf("%w: token %s is revoked or expired", ErrInvalidRefreshToken, current.ID)
+	}
+
+	// Un jeton déjà échangé est rejoué : soit par un voleur, soit par le client légitime
+	// après le vol. On ne peut pas savoir lequel, donc toute la session est fermée.
+	if current.UsedAt != nil {
+		s.revokeFamily(ctx, current.TenantID, current.FamilyID)
+		return nil, fmt.Errorf("%w: reuse of rotated token %s", ErrInvalidRefreshToken, current.ID)
+	}
+
+	// L'utilisateur a pu être supprimé (ou ses rôles changés) depuis la connexion.
+	u, err := s.users.GetUser(ctx, current.TenantID, current.UserID, false)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			s.revokeFamily(ctx, current.TenantID, current.FamilyID)
+			return nil, fmt.Errorf("%w: user %s no longer exists", ErrInvalidRefreshToken, current.UserID)
+		}
+		return nil, err
+	}
+	// Un compte désactivé perd ses sessions au prochain rafraîchissement.
+	if u.EffectiveStatus() != user.StatusActive {
+		s.revokeFamily(ctx, current.TenantID, current.FamilyID)
+		return nil, fmt.Errorf("%w: user %s is %s", ErrInvalidRefreshToken, current.UserID, u.EffectiveStatus())
+	}
+
+	// Consommation du jeton sous contrôle d'ETag : deux rafraîchissements simultanés avec le
+	// même jeton ne peuvent pas réussir tous les deux ; le perdant est traité comme une réutilisation.
+	nextID := uuid.NewString()
+	used := now.UTC()
+	current.UsedAt = &used
+	current.ReplacedBy = nextID
+	if _, err := s.repo.Update(ctx, current); err != nil {
+		if errors.Is(err, database.ErrPreconditionFailed) {
+			s.revokeFamily(ctx, current.TenantID, current.FamilyID)
+			return nil, fmt.Errorf("%w: concurrent reuse of token %s", ErrInvalidRefreshToken, current.ID)
+		}
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.issue(ctx, u, current.FamilyID, nextID)
+}
+
+// Logout révoque toute la famille du jeton présenté.
+func (s *serviceImpl) Logout(ctx context.Context, raw string) error {
+	current, err := s.lookup(ctx, raw)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			return nil
+		}
+		return err
+	}
+	s.revokeFamily(ctx, current.TenantID, current.FamilyID)
+	return nil
+}
+
+// =================================================================================
+// Émission, lecture et révocation des jetons
+// =================================================================================
+
+// issue crée le jeton de rafraîchissement id dans la famille donnée, et le jeton d'accès associé.
+func (s *serviceImpl) issue(ctx context.Context, u *user.User, familyID, id string) (*Tokens, error) {
+	secret, err := auth.NewOpaqueToken(u.TenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := s.now().UTC()
+	token := RefreshToken{
+		TenantID:  secret.TenantID,
+		ID:        secret.ID,
+		UserID:    u.ID,
+		FamilyID:  familyID,
+		TokenHash: secret.Hash(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+		TTL:       int((s.refreshTTL + reuseDetectionWindow).Seconds()),
+	}
+	if _, err := s.repo.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	access, expiresAt, err := s.issuer.Issue(&auth.Principal{Subject: u.ID, TenantID: u.TenantID, Roles: u.Roles})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(expiresAt.Sub(s.now()).Seconds()),
+		RefreshToken: secret.Format(tokenPrefix),
+	}, nil
+}
+
+// lookup retrouve le jeton et vérifie son secret. Tout échec donne ErrInvalidRefreshToken.
+func (s *serviceImpl) lookup(ctx context.Context, raw string) (RefreshToken, error) {
+	secret, ok := auth.ParseOpaqueToken(tokenPrefix, raw)
+	if !ok {
+		return RefreshToken{}, fmt.Errorf("%w: malformed token", ErrInvalidRefreshToken)
+	}
+
+	id := secret.ID
+	token, err := s.repo.Read(ctx, id, secret.TenantID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return RefreshToken{}, fmt.Errorf("%w: unknown token %s", ErrInvalidRefreshToken, id)
+		}
+		return RefreshToken{}, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+
+	if !secret.Matches(token.TokenHash) {
+		return RefreshToken{}, fmt.Errorf("%w: secret mismatch for token %s", ErrInvalidRefreshToken, id)
+	}
+	return token, nil
+}
+
+// revokeFamily révoque tous les jetons encore actifs de la famille. Les erreurs sont
+// journalisées sans être remontées : l'appelant refuse la requête dans tous les cas.
+func (s *serviceImpl) revokeFamily(ctx context.Context, tenantID, familyID string) {
+	q := database.NewQuery[RefreshToken]().Where(database.And(
+		database.Eq("tenantID", tenantID),
+		database.Eq("familyID", familyID),
+		database.IsNull("revokedAt"),
+	))
+	page, err := s.repo.Search(ctx, q, tenantID)
+	if err != nil {
+		logger.Error(ctx, "Failed to list refresh token family", "familyID", familyID, "error", err)
+		return
+	}
+
+	now := s.now().UTC()
+	for _, token := range page.Items {
+		token.RevokedAt = &now
+		if _, err := s.repo.Update(ctx, token); err != nil {
+			logger.Error(ctx, "Failed to revoke refresh token", "tokenID", token.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// =================================================================================
+// Modèles de Données (Entities & DTOs)
+// =================================================================================
+
+// RefreshToken est un jeton de rafraîchissement, à usage unique : chaque utilisation le
+// remplace par un nouveau jeton de la même famille (rotation). Seule l'empreinte est stockée.
+type RefreshToken struct {
+	// TenantID est la clé de partition.
+	TenantID string `json:"tenantID"`
+	ID       string `json:"id"`
+	UserID   string `json:"userID"`
+
+	// FamilyID regroupe les jetons issus d'une même connexion : la réutilisation d'un jeton
+	// déjà échangé (vol probable) ou la déconnexion révoque toute la famille.
+	FamilyID string `json:"familyID"`
+
+	// TokenHash est le SHA-256 du secret (aléatoire, 256 bits).
+	TokenHash string `json:"tokenHash"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// UsedAt et ReplacedBy sont renseignés lors de la rotation.
+	UsedAt     *time.Time `json:"usedAt,omitempty"`
+	ReplacedBy string     `json:"replacedBy,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+
+	// TTL (secondes) : la base purge le document une fois le jeton expiré.
+	TTL int `json:"ttl,omitempty"`
+
+	ETag string `json:"_etag,omitempty"`
+}
+
+// GetID retourne l'identifiant unique.
+func (t RefreshToken) GetID() string {
+	return t.ID
+}
+
+// GetTenantID retourne la clé de partition.
+func (t RefreshToken) GetTenantID() string {
+	return t.TenantID
+}
+
+// GetETag retourne la version du document (implémente database.Versioned).
+func (t RefreshToken) GetETag() string {
+	return t.ETag
+}
+
+// SetETag met à jour la version du document après une écriture.
+func (t *RefreshToken) SetETag(etag string) {
+	t.ETag = etag
+}
+
+// LoginInput est le DTO de connexion. Les emails sont uniques par tenant : le tenant est requis.
+type LoginInput struct {
+	TenantID string `json:"tenantID"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshInput est le DTO de rafraîchissement et de déconnexion.
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Tokens est la réponse de connexion et de rafraîchissement.
+type Tokens struct {
+	AccessToken  string `json:"accessToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// =================================================================================
+// Interfaces (Contrats)
+// =================================================================================
+
+// Service gère les sessions des comptes locaux.
+type Service interface {
+	Login(ctx context.Context, input LoginInput) (*Tokens, error)
+	// Refresh échange un jeton de rafraîchissement contre une nouvelle paire de jetons.
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+	// Logout révoque la session (toute la famille du jeton). Sans effet sur un jeton inconnu.
+	Logout(ctx context.Context, refreshToken string) error
+}
@@ -0,0 +1,161 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/internal/session"
+	"test-api/internal/user"
+	"test-api/kit/auth"
+	"test-api/kit/database/memory"
+)
+
+const (
+	tenantA  = "tenant-A"
+	password = "Excalibur-du-Lac-2024"
+)
+
+var testSecret = []byte("test-secret")
+
+type fixture struct {
+	router        http.Handler
+	users         user.Service
+	authenticator *auth.Authenticator
+	arthur        *user.User
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	users := user.NewService(user.NewCosmosRepository(memory.NewAdapter[user.User]()))
+	arthur, err := users.CreateUser(context.Background(), tenantA, user.CreateUserInput{
+		Email: "arthur@kaamelott.com", Nom: "Pendragon", Password: password,
+	})
+	require.NoError(t, err)
+
+	issuer, err := auth.NewTokenIssuer(testSecret, "test-api", "", 0)
+	require.NoError(t, err)
+	authenticator, err := auth.NewHMACAuthenticator(testSecret, auth.WithIssuers("test-api"))
+	require.NoError(t, err)
+
+	service := session.NewService(memory.NewAdapter[session.RefreshToken](), users, issuer)
+	r := chi.NewRouter()
+	r.Route("/auth", session.NewHandler(service).RegisterRoutes)
+
+	return fixture{router: r, users: users, authenticator: authenticator, arthur: arthur}
+}
+
+func (f fixture) post(t *testing.T, target string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	f.router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, target, bytes.NewReader(data)))
+	return rr
+}
+
+func (f fixture) login(t *testing.T) session.Tokens {
+	t.Helper()
+	rr := f.post(t, "/auth/login", session.LoginInput{TenantID: tenantA, Email: "arthur@kaamelott.com", Password: password})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	var tokens session.Tokens
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&tokens))
+	return tokens
+}
+
+func TestLogin(t *testing.T) {
+	f := newFixture(t)
+
+	tokens := f.login(t)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Positive(t, tokens.ExpiresIn)
+	require.NotEmpty(t, tokens.RefreshToken)
+
+	// Le jeton d'accès est accepté par le middleware d'authentification.
+	p, err := f.authenticator.Authenticate(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.arthur.ID, p.Subject)
+	assert.Equal(t, tenantA, p.TenantID)
+	assert.True(t, p.Can(user.PermissionRead))
+
+	tests := []struct {
+		name           string
+		input          session.LoginInput
+		expectedStatus int
+	}{
+		{"Mauvais mot de passe", session.LoginInput{TenantID: tenantA, Email: "arthur@kaamelott.com", Password: "nope"}, http.StatusUnauthorized},
+		{"Email inconnu", session.LoginInput{TenantID: tenantA, Email: "merlin@kaamelott.com", Password: password}, http.StatusUnauthorized},
+		{"Champs manquants", session.LoginInput{}, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := f.post(t, "/auth/login", tc.input)
+			assert.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestRefresh_Rotation(t *testing.T) {
+	f := newFixture(t)
+	first := f.login(t)
+
+	// 1. Le jeton est échangé contre une nouvelle paire.
+	rr := f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: first.RefreshToken})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var second session.Tokens
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&second))
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// 2. Rejouer l'ancien jeton révèle un vol : toute la session est révoquée...
+	assert.Equal(t, http.StatusUnauthorized, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: first.RefreshToken}).Code)
+	// ... y compris le jeton légitime le plus récent.
+	assert.Equal(t, http.StatusUnauthorized, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: second.RefreshToken}).Code)
+
+	// 3. Une nouvelle connexion ouvre une session indépendante.
+	third := f.login(t)
+	assert.Equal(t, http.StatusOK, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: third.RefreshToken}).Code)
+}
+
+func TestLogout(t *testing.T) {
+	f := newFixture(t)
+	tokens := f.login(t)
+	other := f.login(t)
+
+	require.Equal(t, http.StatusNoContent, f.post(t, "/auth/logout", session.RefreshInput{RefreshToken: tokens.RefreshToken}).Code)
+	assert.Equal(t, http.StatusUnauthorized, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: tokens.RefreshToken}).Code)
+
+	// Les autres sessions (autre appareil) ne sont pas affectées.
+	assert.Equal(t, http.StatusOK, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: other.RefreshToken}).Code)
+
+	// Déconnexion idempotente, sans révéler si le jeton existait.
+	assert.Equal(t, http.StatusNoContent, f.post(t, "/auth/logout", session.RefreshInput{RefreshToken: tokens.RefreshToken}).Code)
+	assert.Equal(t, http.StatusNoContent, f.post(t, "/auth/logout", session.RefreshInput{RefreshToken: "rt_garbage"}).Code)
+}
+
+func TestRefresh_DeletedUser(t *testing.T) {
+	f := newFixture(t)
+	tokens := f.login(t)
+
+	require.NoError(t, f.users.DeleteUser(context.Background(), tenantA, f.arthur.ID, "admin", ""))
+
+	assert.Equal(t, http.StatusUnauthorized, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: tokens.RefreshToken}).Code)
+}
+
+func TestRefresh_DisabledUser(t *testing.T) {
+	f := newFixture(t)
+	tokens := f.login(t)
+
+	disabled := user.StatusDisabled
+	_, err := f.users.UpdateUser(context.Background(), tenantA, f.arthur.ID, user.UpdateUserInput{Status: &disabled}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, f.post(t, "/auth/refresh", session.RefreshInput{RefreshToken: tokens.RefreshToken}).Code)
+}
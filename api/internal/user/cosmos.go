@@ -2,29 +2,22 @@ package user
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
-
-	"test-api/kit/database/cosmos"
+	"test-api/kit/database"
 )
 
 // cosmosRepository est l'implémentation spécifique du Repository pour le domaine User.
+// Il ne dépend que du contrat générique database.Repository : en production on lui passe un
+// cosmos.Adapter, en test ou en local sans Azure un memory.Adapter (même sémantique).
 type cosmosRepository struct {
-	genericAdapter  *cosmos.Adapter[User]
-	containerClient *azcosmos.ContainerClient
+	genericAdapter database.Repository[User]
 }
 
-func NewCosmosRepository(adapter *cosmos.Adapter[User]) Repository {
-	// On suppose que le kit a la méthode Container()
+func NewCosmosRepository(adapter database.Repository[User]) Repository {
 	return &cosmosRepository{
 		genericAdapter: adapter,
-		// On récupère le client bas niveau depuis l'adaptateur
-		containerClient: adapter.Container(),
 	}
 }
 
@@ -34,19 +27,22 @@ func NewCosmosRepository(adapter *cosmos.Adapter[User]) Repository {
 
 func (r *cosmosRepository) Create(ctx context.Context, user *User) error {
 	// L'adapteur générique sait comment appeler GetTenantID() grâce à l'interface Entity
-	return r.genericAdapter.Create(ctx, *user)
+	created, err := r.genericAdapter.Create(ctx, *user)
+	if err != nil {
+		return err
+	}
+	*user = created
+	return nil
 }
 
 func (r *cosmosRepository) GetByID(ctx context.Context, tenantID string, id string) (*User, error) {
 	user, err := r.genericAdapter.Read(ctx, id, tenantID)
 
 	if err != nil {
-		// Gestion spécifique de l'erreur "not found" de Cosmos pour que ne soit aps considéré comme une erreur technique.
-		var responseErr *azcore.ResponseError
-		if errors.As(err, &responseErr) {
-			if responseErr.StatusCode == 404 {
-				return nil, nil
-			}
+		// "not found" n'est pas considéré comme une erreur technique.
+		// L'adapteur a déjà traduit le 404 Cosmos en sentinelle générique.
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, nil
 		}
 		// Sinon, c'est une vraie erreur technique (timeout, auth, etc.)
 		return nil, err
@@ -59,85 +55,55 @@ func (r *cosmosRepository) GetByID(ctx context.Context, tenantID string, id stri
 }
 
 func (r *cosmosRepository) Update(ctx context.Context, user *User) error {
-	return r.genericAdapter.Update(ctx, *user)
+	// User implémente database.Versioned : l'adapteur envoie user.ETag en If-Match.
+	updated, err := r.genericAdapter.Update(ctx, *user)
+	if err != nil {
+		return err
+	}
+	*user = updated
+	return nil
 }
 
-func (r *cosmosRepository) Delete(ctx context.Context, tenantID string, id string) error {
-	return r.genericAdapter.Delete(ctx, id, tenantID)
+func (r *cosmosRepository) Delete(ctx context.Context, tenantID string, id string, etag string) error {
+	return r.genericAdapter.Delete(ctx, id, tenantID, database.IfMatch(etag))
 }
 
 // =================================================================================
-// Méthodes de Recherche Spécifiques (Implémentation directe ici)
+// Méthodes de Recherche Spécifiques
 // =================================================================================
 
 // Search implémente la recherche multicritères spécifique aux users.
-func (r *cosmosRepository) Search(ctx context.Context, tenantID string, filter Filter) ([]User, error) {
-	pk := azcosmos.NewPartitionKeyString(tenantID)
-
-	// Construction de la requête SQL de base
-	// IMPORTANT : On filtre TOUJOURS par tenantID dans la clause WHERE pour la sécurité.
-	queryBuilder := strings.Builder{}
-	queryBuilder.WriteString("SELECT * FROM c WHERE c.tenantID = @tenantId")
-
-	// Initialisation des paramètres avec le tenantId
-	params := []azcosmos.QueryParameter{
-		{Name: "@tenantId", Value: tenantID},
-	}
+// On traduit le Filter métier en database.Query ; la compilation SQL est faite par l'adapteur.
+func (r *cosmosRepository) Search(ctx context.Context, tenantID string, filter Filter) ([]User, string, error) {
+	// IMPORTANT : On filtre TOUJOURS par tenantID dans la clause WHERE pour la sécurité,
+	// en plus de la clé de partition.
+	query := database.NewQuery[User]().
+		Where(database.Eq("tenantID", tenantID)).
+		OrderBy(database.Desc("_ts")).
+		Page(filter.Limit, filter.Continuation)
 
 	// Ajout dynamique des filtres optionnels
 	if filter.Nom != nil {
-		queryBuilder.WriteString(" AND c.nom = @nom")
-		params = append(params, azcosmos.QueryParameter{Name: "@nom", Value: *filter.Nom})
+		query = query.Where(database.Eq("nom", *filter.Nom))
 	}
-
 	if filter.Email != nil {
-		queryBuilder.WriteString(" AND c.email = @email")
-		params = append(params, azcosmos.QueryParameter{Name: "@email", Value: *filter.Email})
+		query = query.Where(database.Eq("email", *filter.Email))
 	}
-
-	// Ajout de la pagination (ORDER BY obligatoire pour OFFSET/LIMIT)
-	queryBuilder.WriteString(" ORDER BY c._ts DESC")
-
-	if filter.Limit > 0 {
-		queryBuilder.WriteString(" OFFSET @offset LIMIT @limit")
-		params = append(params, azcosmos.QueryParameter{Name: "@offset", Value: filter.Offset})
-		params = append(params, azcosmos.QueryParameter{Name: "@limit", Value: filter.Limit})
+	if filter.Status != nil {
+		// Les comptes antérieurs aux états n'ont pas de champ "status" : ils sont actifs.
+		if *filter.Status == StatusActive {
+			query = query.Where(database.Or(database.Eq("status", StatusActive), database.IsNull("status")))
+		} else {
+			query = query.Where(database.Eq("status", *filter.Status))
+		}
 	}
-
-	// Préparation des options de requête
-	queryOptions := azcosmos.QueryOptions{
-		QueryParameters: params,
+	if !filter.IncludeDeleted {
+		query = query.Where(database.IsNull("deletedAt"))
 	}
 
-	// Exécution avec le Pager (via le container client brut)
-	// Note : Votre Adapteur générique pourrait exposer une méthode "ExecuteQuery" pour éviter
-	// d'avoir accès au containerClient ici, mais pour l'instant, faisons simple.
-	pager := r.containerClient.NewQueryItemsPager(queryBuilder.String(), pk, &queryOptions)
-
-	// Boucle de récupération des résultats
-	// Vous pouvez réutiliser la méthode privée de votre adapteur si elle existe,
-	// sinon on duplique cette boucle standard ici.
-	var results []User
-
-	for pager.More() {
-		response, err := pager.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("cosmos query failed: %w", err)
-		}
-
-		for _, bytes := range response.Items {
-			// Désérialisation via la méthode générique de l'adapteur si possible,
-			// sinon on le fait à la main ici. Votre adapteur semble faire le unmarshal
-			// à l'intérieur de ses méthodes Read/Search, mais pas via une fonction helper exposée.
-			// On le refait donc ici :
-			var item User
-			if err := json.Unmarshal(bytes, &item); err != nil {
-				// Log error, continue or return...
-				return nil, fmt.Errorf("failed to unmarshal user json: %w", err)
-			}
-			results = append(results, item)
-		}
+	page, err := r.genericAdapter.Search(ctx, query, tenantID)
+	if err != nil {
+		return nil, "", fmt.Errorf("cosmos query failed: %w", err)
 	}
-
-	return results, nil
+	return page.Items, page.Continuation, nil
 }
@@ -1,25 +1,39 @@
 package user
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+
 	"test-api/kit/api"
+	"test-api/kit/apperr"
+	"test-api/kit/auth"
+	"test-api/kit/pagination"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// Permissions du domaine User (voir auth.DefaultRoles pour les rôles qui les accordent).
+const (
+	PermissionRead  = "users:read"
+	PermissionWrite = "users:write"
+	// PermissionAdmin donne accès aux options d'administration (utilisateurs supprimés, rôles).
+	PermissionAdmin = "users:admin"
+)
+
 // Handler gère les requêtes HTTP pour le domaine User.
 type Handler struct {
 	service Service
+	cursors *pagination.Signer
 }
 
-func NewHandler(s Service) *Handler {
+// NewHandler crée le handler. cursors signe les curseurs de pagination de GET /users.
+func NewHandler(s Service, cursors *pagination.Signer) *Handler {
 	return &Handler{
 		service: s,
+		cursors: cursors,
 	}
 }
 
@@ -27,13 +41,35 @@ func NewHandler(s Service) *Handler {
 //
 // GET /users : Recherche des utilisateurs
 // POST /users : Création d'un utilisateur
-// GET /users/{id} : Récupération d'un utilisateur par son ID
+// GET /users/{id} : Récupération d'un utilisateur par son ID (en-tête ETag)
+// PATCH /users/{id} : Modification partielle, JSON Merge Patch (If-Match optionnel, 412 si version périmée)
+// DELETE /users/{id} : Suppression logique d'un utilisateur (If-Match optionnel)
+// POST /users/{id}:restore : Restauration d'un utilisateur supprimé
+// POST /users/invitations : Invitation d'un utilisateur (compte en attente, lien envoyé par le Notifier)
+//
+// GET accepte ?includeDeleted=true pour voir les utilisateurs supprimés (PermissionAdmin).
+// La lecture exige PermissionRead, toute modification PermissionWrite (403 sinon) ;
+// attribuer des rôles (champ "roles" de l'invitation ou du PATCH) et changer le statut d'un compte
+// exigent PermissionAdmin. Sans PermissionAdmin, seuls les simples membres peuvent être supprimés.
 func (h *Handler) RegisterRoutes(r chi.Router) {
-	r.Post("/", h.Create)
-	r.Get("/", h.Search)
-	r.Get("/{id}", h.GetByID)
-	// r.Put("/{id}", h.Update)
-	// r.Delete("/{id}", h.Delete)
+	read := r.With(auth.Require(PermissionRead))
+	read.Get("/", h.Search)
+	read.Get("/{id}", h.GetByID)
+
+	write := r.With(auth.Require(PermissionWrite))
+	write.Post("/", h.Create)
+	write.Patch("/{id}", h.Update)
+	write.Delete("/{id}", h.Delete)
+	write.Post("/{id}:restore", h.Restore)
+	write.Post("/invitations", h.Invite)
+}
+
+// RegisterPublicRoutes définit les routes accessibles sans authentification
+// (à monter hors du middleware d'authentification, sous /auth).
+//
+// POST /auth/invitations/{token}/accept : Acceptation d'une invitation (choix du mot de passe)
+func (h *Handler) RegisterPublicRoutes(r chi.Router) {
+	r.Post("/invitations/{token}/accept", h.AcceptInvitation)
 }
 
 // =================================================================================
@@ -45,16 +81,16 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Récupération du tenantID pour faire un return rapide si absent
-	tenantID, err := getTenantIDFromContext(ctx)
+	tenantID, err := auth.TenantID(ctx)
 	if err != nil {
-		api.RespondWithError(w, err)
+		api.RespondWithError(w, r, err)
 		return
 	}
 
 	// Décodage du corps JSON vers le DTO d'entrée (CreateUserInput)
 	var input CreateUserInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		api.RespondWithError(w, err)
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
 		return
 	}
 	defer r.Body.Close()
@@ -62,77 +98,348 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	// Appel à la couche métier
 	newUser, err := h.service.CreateUser(ctx, tenantID, input)
 	if err != nil {
-		fmt.Printf("[SERVICE ERROR] Erreur lors de la création de l'utilisateur: %v\n", err)
-		// TODO Ici, vous pourriez vérifier le type d'erreur pour renvoyer 400 ou 409 (conflit)
-		// Pour simplifier, on renvoie 500 pour l'instant.
-		api.RespondWithError(w, err)
+		// Le statut (400, 409...) est déduit de la catégorie de l'erreur par api.RespondWithError.
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	api.SetETag(w, newUser.ETag)
+	api.RespondWithJSON(w, r, http.StatusCreated, newUser.Public())
+}
+
+// Invite POST /users/invitations
+func (h *Handler) Invite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID, err := auth.TenantID(ctx)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	var input InviteUserInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
+		return
+	}
+	defer r.Body.Close()
+
+	if input.Roles != nil {
+		if err := requireAdmin(r, "roles"); err != nil {
+			api.RespondWithError(w, r, err)
+			return
+		}
+	}
+
+	invited, err := h.service.InviteUser(ctx, tenantID, input)
+	if err != nil {
+		api.RespondWithError(w, r, err)
 		return
 	}
 
-	api.RespondWithJSON(w, http.StatusCreated, newUser)
+	api.SetETag(w, invited.ETag)
+	api.RespondWithJSON(w, r, http.StatusCreated, invited.Public())
+}
+
+// AcceptInvitation POST /auth/invitations/{token}/accept
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var input AcceptInvitationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.RespondWithError(w, r, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON body", err))
+		return
+	}
+	defer r.Body.Close()
+
+	activated, err := h.service.AcceptInvitation(r.Context(), chi.URLParam(r, "token"), input)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	api.RespondWithJSON(w, r, http.StatusOK, activated.Public())
 }
 
 // GetByID gère GET /users/{id}
 func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	tenantID, err := getTenantIDFromContext(ctx)
+	tenantID, err := auth.TenantID(ctx)
 	if err != nil {
-		api.RespondWithError(w, err)
+		api.RespondWithError(w, r, err)
 		return
 	}
 
 	// Extraction de l'ID depuis l'URL (syntaxe dépendant de votre routeur, ici Chi)
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		api.RespondWithError(w, errors.New("missing id parameter"))
+		api.RespondWithError(w, r, apperr.New(apperr.ErrInvalidInput, "missing id parameter"))
+		return
+	}
+
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		api.RespondWithError(w, r, err)
 		return
 	}
 
 	// Appel couche métier
-	user, err := h.service.GetUser(ctx, tenantID, id)
+	user, err := h.service.GetUser(ctx, tenantID, id, includeDeleted)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	api.SetETag(w, user.ETag)
+	api.RespondWithJSON(w, r, http.StatusOK, user.Public())
+}
+
+// Update gère PATCH /users/{id} (Content-Type: application/merge-patch+json, RFC 7396)
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID, err := auth.TenantID(ctx)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	patch, err := api.DecodeMergePatch(r)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	input, err := parseUserPatch(patch)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+	if input.Roles != nil {
+		if err := requireAdmin(r, "roles"); err != nil {
+			api.RespondWithError(w, r, err)
+			return
+		}
+	}
+	// Désactiver un compte coupe l'accès de son titulaire : même niveau que l'attribution de rôles.
+	if input.Status != nil {
+		if err := requireAdmin(r, "status"); err != nil {
+			api.RespondWithError(w, r, err)
+			return
+		}
+	}
+
+	user, err := h.service.UpdateUser(ctx, tenantID, chi.URLParam(r, "id"), input, api.IfMatch(r))
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	api.SetETag(w, user.ETag)
+	api.RespondWithJSON(w, r, http.StatusOK, user.Public())
+}
+
+// Delete gère DELETE /users/{id}
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID, err := auth.TenantID(ctx)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	target, err := h.service.GetUser(ctx, tenantID, id, false)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+	if err := requireGrantable(r, target); err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	deletedBy := auth.UserID(ctx)
+	if err := h.service.DeleteUser(ctx, tenantID, id, deletedBy, api.IfMatch(r)); err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Restore gère POST /users/{id}:restore
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID, err := auth.TenantID(ctx)
 	if err != nil {
-		// TODO: Vérifier si l'erreur est de type "Not Found" pour renvoyer 404
-		api.RespondWithError(w, err)
+		api.RespondWithError(w, r, err)
 		return
 	}
 
-	api.RespondWithJSON(w, http.StatusOK, user)
+	user, err := h.service.RestoreUser(ctx, tenantID, chi.URLParam(r, "id"), api.IfMatch(r))
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	api.SetETag(w, user.ETag)
+	api.RespondWithJSON(w, r, http.StatusOK, user.Public())
+}
+
+// searchResponse est le corps de GET /users : une page et le curseur de la suivante.
+type searchResponse struct {
+	Items      []User `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
-// Search gère GET /users?nom=...&email=...&limit=10
+// Search gère GET /users?nom=...&email=...&limit=10&cursor=...
+// La page suivante est indiquée par "nextCursor" et par l'en-tête Link rel="next".
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	tenantID, err := getTenantIDFromContext(ctx)
+	tenantID, err := auth.TenantID(ctx)
 	if err != nil {
-		api.RespondWithError(w, err)
+		api.RespondWithError(w, r, err)
 		return
 	}
 
 	// 1. Parsing des query parameters dans la struct Filter
-	filter := parseSearchFilter(r)
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
 
-	// 2. Appel couche métier
-	users, err := h.service.SearchUsers(ctx, tenantID, filter)
+	// 2. Le curseur n'est valable que pour ce tenant et ces critères.
+	filter.Continuation, err = h.cursors.Decode(r.URL.Query().Get(api.CursorParam), tenantID, filter.bindingKey())
 	if err != nil {
-		api.RespondWithError(w, err)
+		api.RespondWithError(w, r, err)
 		return
 	}
 
-	// 3. Réponse (Si users est nil, json.Marshal renverra "null", on préfère souvent "[]")
+	// 3. Appel couche métier
+	users, next, err := h.service.SearchUsers(ctx, tenantID, filter)
+	if err != nil {
+		api.RespondWithError(w, r, err)
+		return
+	}
+
+	// 4. Réponse (Si users est nil, json.Marshal renverra "null", on préfère souvent "[]")
 	if users == nil {
 		users = []User{}
 	}
-	api.RespondWithJSON(w, http.StatusOK, users)
+	nextCursor := h.cursors.Encode(next, tenantID, filter.bindingKey())
+	api.SetNextLink(w, r, nextCursor)
+	for i := range users {
+		users[i] = users[i].Public()
+	}
+	api.RespondWithJSON(w, r, http.StatusOK, searchResponse{Items: users, NextCursor: nextCursor})
 }
 
 // =================================================================================
 // Helpers privés au Handler (À déplacer potentiellement dans kit/api/http.go)
 // =================================================================================
 
+// requireAdmin refuse (403) l'option d'administration au principal qui n'a pas PermissionAdmin.
+func requireAdmin(r *http.Request, option string) error {
+	p, ok := auth.PrincipalFrom(r.Context())
+	if !ok {
+		return auth.ErrNoPrincipal
+	}
+	if !p.Can(PermissionAdmin) {
+		return fmt.Errorf("%w: %s requires %q", auth.ErrForbidden, option, PermissionAdmin)
+	}
+	return nil
+}
+
+// requireGrantable refuse (403) d'agir sur un compte dont le principal ne pourrait pas attribuer les rôles :
+// sans PermissionAdmin, on n'attribue que le rôle par défaut (membre), on ne supprime donc pas un manager
+// ou un administrateur.
+func requireGrantable(r *http.Request, target *User) error {
+	p, ok := auth.PrincipalFrom(r.Context())
+	if !ok {
+		return auth.ErrNoPrincipal
+	}
+	if p.Can(PermissionAdmin) {
+		return nil
+	}
+	for _, role := range target.Roles {
+		if role != auth.RoleMember {
+			return fmt.Errorf("%w: user has role %q, requires %q", auth.ErrForbidden, role, PermissionAdmin)
+		}
+	}
+	return nil
+}
+
+// parseIncludeDeleted lit l'option d'administration ?includeDeleted=true.
+func parseIncludeDeleted(r *http.Request) (bool, error) {
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("includeDeleted"))
+	if !includeDeleted {
+		return false, nil
+	}
+	if err := requireAdmin(r, "includeDeleted"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// parseUserPatch traduit un JSON Merge Patch en UpdateUserInput.
+// null remet le champ à vide (refusé ensuite par le service pour les champs obligatoires),
+// les champs en lecture seule et les champs inconnus sont refusés.
+func parseUserPatch(patch map[string]json.RawMessage) (UpdateUserInput, error) {
+	var input UpdateUserInput
+	var errs []error
+
+	for field, raw := range patch {
+		if field == "roles" {
+			// null retire tous les rôles : refusé par le service (un compte a au moins un rôle).
+			roles := []string{}
+			if !api.IsNull(raw) {
+				if err := json.Unmarshal(raw, &roles); err != nil {
+					errs = append(errs, ErrInvalidInput{Field: field, Message: "must be an array of strings"})
+					continue
+				}
+			}
+			input.Roles = &roles
+			continue
+		}
+
+		var target **string
+		switch field {
+		case "email":
+			target = &input.Email
+		case "nom":
+			target = &input.Nom
+		case "prenom":
+			target = &input.Prenom
+		case "status":
+			target = &input.Status
+		case "id", "tenantID", "_etag":
+			errs = append(errs, ErrInvalidInput{Field: field, Message: "read-only field"})
+			continue
+		default:
+			errs = append(errs, ErrInvalidInput{Field: field, Message: "unknown field"})
+			continue
+		}
+
+		value := ""
+		if !api.IsNull(raw) {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				errs = append(errs, ErrInvalidInput{Field: field, Message: "must be a string"})
+				continue
+			}
+		}
+		*target = &value
+	}
+
+	return input, errors.Join(errs...)
+}
+
 // parseSearchFilter extrait les paramètres d'URL pour construire le filtre.
-func parseSearchFilter(r *http.Request) Filter {
+func parseSearchFilter(r *http.Request) (Filter, error) {
 	q := r.URL.Query()
 	filter := Filter{}
 
@@ -143,6 +450,14 @@ func parseSearchFilter(r *http.Request) Filter {
 	if val := q.Get("email"); val != "" {
 		filter.Email = &val
 	}
+	if val := q.Get("status"); val != "" {
+		filter.Status = &val
+	}
+	includeDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		return Filter{}, err
+	}
+	filter.IncludeDeleted = includeDeleted
 
 	// Pagination avec valeurs par défaut
 	limit, _ := strconv.Atoi(q.Get("limit"))
@@ -151,44 +466,5 @@ func parseSearchFilter(r *http.Request) Filter {
 	}
 	filter.Limit = limit
 
-	offset, _ := strconv.Atoi(q.Get("offset"))
-	if offset < 0 {
-		offset = 0
-	}
-	filter.Offset = offset
-
-	return filter
-}
-
-// EXEMPLE FICTIF d'implementation du middleware :
-// tenantID, ok := ctx.Value("tenant_id_key").(string)
-// if !ok || tenantID == "" { return "", fmt.Errorf("no tenant id found") }
-// return tenantID, nil
-
-// Pour que l'exemple compile, je retourne une valeur fixe.
-// A REMPLACER IMPERATIVEMENT PAR VOTRE LOGIQUE D'AUTH.
-
-// getTenantIDFromContext est un MOCK.
-// Dans votre vrai projet, cela devrait être une fonction exportée de votre package `kit/auth`.
-// Elle doit extraire le tenant ID que votre middleware d'authentification a placé dans le contexte.
-// TODO: Implémentez cette fonction selon votre logique d'authentification.
-func getTenantIDFromContext(ctx context.Context) (string, error) {
-	// 1. On essaie de lire la valeur injectée par le test (ou plus tard, le middleware)
-	// On utilise la constante publique définie dans user.go
-	val := ctx.Value(TenantIDContextKey)
-
-	// 2. Si ça existe et que c'est une string non vide, on l'utilise.
-	if tenantID, ok := val.(string); ok && tenantID != "" {
-		return tenantID, nil
-	}
-
-	// 3. Fallback ou Erreur.
-	// Pour le test, si on n'a rien mis dans le contexte, c'est une erreur.
-	// Cela forcera le handler à renvoyer une 401 Unauthorized, ce qui est correct.
-	return "", fmt.Errorf("no tenant ID found in context (mock implementation)")
-
-	// Note : Si vous voulez garder un comportement par défaut "tenant_admin" pour
-	// d'autres tests locaux hors de ce fichier, vous pouvez le remettre ici en fallback,
-	// mais cela risque de masquer des erreurs dans vos tests.
-	// return "tenant_admin", nil
+	return filter, nil
 }
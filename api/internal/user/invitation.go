@@ -0,0 +1,84 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"test-api/kit/apperr"
+)
+
+// Jeton d'invitation : base64url(charge utile JSON) "." base64url(HMAC-SHA256).
+// La signature garantit que le jeton a été émis par l'API (tenant, utilisateur, échéance
+// infalsifiables) ; le nonce, dont seule l'empreinte est stockée sur l'utilisateur,
+// le rend à usage unique et révocable (une nouvelle invitation invalide la précédente).
+
+// ErrInvalidInvitation couvre tous les cas d'invitation inutilisable (altérée, expirée, déjà acceptée).
+var ErrInvalidInvitation = apperr.New(apperr.ErrNotFound, "invitation not found or expired")
+
+// DefaultInvitationTTL est la durée de validité d'une invitation.
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+type invitationClaims struct {
+	TenantID  string `json:"t"`
+	UserID    string `json:"u"`
+	Nonce     string `json:"n"`
+	ExpiresAt int64  `json:"e"`
+}
+
+func newInvitationNonce() (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate invitation nonce: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(nonce), nil
+}
+
+func signInvitation(key []byte, c invitationClaims) string {
+	body, _ := json.Marshal(c)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(body) + "." + enc.EncodeToString(invitationMAC(key, body))
+}
+
+// parseInvitation vérifie la signature et l'échéance du jeton.
+func parseInvitation(key []byte, token string, now time.Time) (invitationClaims, error) {
+	enc := base64.RawURLEncoding
+	bodyPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return invitationClaims{}, ErrInvalidInvitation
+	}
+	body, err := enc.DecodeString(bodyPart)
+	if err != nil {
+		return invitationClaims{}, ErrInvalidInvitation
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, invitationMAC(key, body)) {
+		return invitationClaims{}, ErrInvalidInvitation
+	}
+
+	var c invitationClaims
+	if err := json.Unmarshal(body, &c); err != nil {
+		return invitationClaims{}, ErrInvalidInvitation
+	}
+	if !now.Before(time.Unix(c.ExpiresAt, 0)) {
+		return invitationClaims{}, ErrInvalidInvitation
+	}
+	return c, nil
+}
+
+func invitationMAC(key, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func hashNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:])
+}
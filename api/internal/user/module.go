@@ -0,0 +1,26 @@
+package user
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"test-api/kit/pagination"
+)
+
+// ModuleName identifie le domaine User auprès des autres modules (server.Module).
+const ModuleName = "users"
+
+// Module expose le domaine User au serveur : routes /users et acceptation des invitations.
+type Module struct {
+	handler *Handler
+}
+
+func NewModule(s Service, cursors *pagination.Signer) *Module {
+	return &Module{handler: NewHandler(s, cursors)}
+}
+
+func (m *Module) Name() string           { return ModuleName }
+func (m *Module) MountPath() string      { return "/users" }
+func (m *Module) Dependencies() []string { return nil }
+
+func (m *Module) RegisterRoutes(r chi.Router)       { m.handler.RegisterRoutes(r) }
+func (m *Module) RegisterPublicRoutes(r chi.Router) { m.handler.RegisterPublicRoutes(r) }
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"test-api/kit/logger"
+)
+
+// Invitation est le message remis à l'invité.
+type Invitation struct {
+	TenantID  string
+	UserID    string
+	Email     string
+	Nom       string
+	Prenom    string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// Notifier achemine les invitations (email, SMS...). L'implémentation est choisie au démarrage :
+// un fournisseur d'emails en production, LogNotifier ou FileNotifier en local.
+type Notifier interface {
+	SendInvitation(ctx context.Context, invitation Invitation) error
+}
+
+// LogNotifier écrit les invitations dans les logs. Le lien est un secret : à ne pas utiliser
+// en production, où les logs sont largement accessibles.
+type LogNotifier struct{}
+
+func (LogNotifier) SendInvitation(ctx context.Context, inv Invitation) error {
+	logger.Info(ctx, "Invitation envoyée",
+		"tenantID", inv.TenantID,
+		"userID", inv.UserID,
+		"email", inv.Email,
+		"link", inv.Link,
+		"expiresAt", inv.ExpiresAt,
+	)
+	return nil
+}
+
+// FileNotifier dépose chaque invitation dans un fichier texte du répertoire Dir
+// (pratique pour les tests manuels : le lien est copié depuis le fichier).
+type FileNotifier struct {
+	Dir string
+}
+
+func (n FileNotifier) SendInvitation(ctx context.Context, inv Invitation) error {
+	if err := os.MkdirAll(n.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create invitation directory: %w", err)
+	}
+
+	body := fmt.Sprintf("To: %s\nSubject: Invitation\n\nBonjour %s %s,\n\nVous êtes invité à rejoindre votre espace. "+
+		"Choisissez votre mot de passe avant le %s :\n\n%s\n",
+		inv.Email, inv.Prenom, inv.Nom, inv.ExpiresAt.Format(time.RFC1123), inv.Link)
+
+	path := filepath.Join(n.Dir, fmt.Sprintf("%s-%s.txt", inv.TenantID, inv.UserID))
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		return fmt.Errorf("failed to write invitation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package user
+
+import (
+	"net/http"
+
+	"test-api/kit/api"
+	"test-api/kit/openapi"
+)
+
+// DescribeRoutes décrit les routes du module dans le document OpenAPI (voir RegisterRoutes
+// et RegisterPublicRoutes) : routes est relatif à /api/users, public à /api/auth.
+func (m *Module) DescribeRoutes(routes, public *openapi.Spec) {
+	one := func(description string) *openapi.Response {
+		return routes.JSON(description, User{}).WithHeader("ETag", "Version de l'utilisateur, à renvoyer en If-Match")
+	}
+	ifMatch := openapi.HeaderParam("If-Match", "Version attendue (ETag) : 412 si l'utilisateur a changé entre-temps")
+	includeDeleted := openapi.Query("includeDeleted", "Inclut les utilisateurs supprimés (permission users:admin, 403 sinon)", &openapi.Schema{Type: "boolean"})
+	minLimit, maxLimit := 1.0, 100.0
+
+	routes.Add(http.MethodGet, "/", openapi.Operation{
+		OperationID: "searchUsers",
+		Summary:     "Recherche des utilisateurs (pagination par curseur)",
+		Parameters: []*openapi.Parameter{
+			openapi.Query("nom", "Nom exact", &openapi.Schema{Type: "string"}),
+			openapi.Query("email", "Email exact", &openapi.Schema{Type: "string"}),
+			openapi.Query("status", "État du compte", &openapi.Schema{Type: "string", Enum: []any{StatusInvited, StatusActive, StatusDisabled}}),
+			includeDeleted,
+			openapi.Query("limit", "Taille de page (20 par défaut)", &openapi.Schema{Type: "integer", Minimum: &minLimit, Maximum: &maxLimit}),
+			openapi.Query(api.CursorParam, "Curseur de la page suivante (nextCursor de la réponse précédente)", &openapi.Schema{Type: "string"}),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": routes.JSON("Page d'utilisateurs", searchResponse{}).WithHeader("Link", `Page suivante (rel="next"), absent sur la dernière page`),
+		},
+	})
+	routes.Add(http.MethodPost, "/", openapi.Operation{
+		OperationID: "createUser",
+		Summary:     "Crée un utilisateur",
+		RequestBody: routes.Body("application/json", CreateUserInput{}),
+		Responses:   map[string]*openapi.Response{"201": one("Utilisateur créé")},
+	})
+	routes.Add(http.MethodGet, "/{id}", openapi.Operation{
+		OperationID: "getUser",
+		Summary:     "Récupère un utilisateur",
+		Parameters:  []*openapi.Parameter{includeDeleted},
+		Responses:   map[string]*openapi.Response{"200": one("Utilisateur")},
+	})
+	routes.Add(http.MethodPatch, "/{id}", openapi.Operation{
+		OperationID: "updateUser",
+		Summary:     "Modifie un utilisateur (JSON Merge Patch, RFC 7396)",
+		Parameters:  []*openapi.Parameter{ifMatch},
+		RequestBody: routes.Body(api.MergePatchContentType, UpdateUserInput{}),
+		Responses:   map[string]*openapi.Response{"200": one("Utilisateur modifié")},
+	})
+	routes.Add(http.MethodDelete, "/{id}", openapi.Operation{
+		OperationID: "deleteUser",
+		Summary:     "Supprime logiquement un utilisateur",
+		Parameters:  []*openapi.Parameter{ifMatch},
+		Responses:   map[string]*openapi.Response{"204": openapi.NoContent("Utilisateur supprimé")},
+	})
+	routes.Add(http.MethodPost, "/{id}:restore", openapi.Operation{
+		OperationID: "restoreUser",
+		Summary:     "Restaure un utilisateur supprimé",
+		Parameters:  []*openapi.Parameter{ifMatch},
+		Responses:   map[string]*openapi.Response{"200": one("Utilisateur restauré")},
+	})
+	routes.Add(http.MethodPost, "/invitations", openapi.Operation{
+		OperationID: "inviteUser",
+		Summary:     "Invite un utilisateur (le lien d'invitation lui est envoyé)",
+		RequestBody: routes.Body("application/json", InviteUserInput{}),
+		Responses:   map[string]*openapi.Response{"201": one("Utilisateur invité")},
+	})
+
+	public.Add(http.MethodPost, "/invitations/{token}/accept", openapi.Operation{
+		OperationID: "acceptInvitation",
+		Summary:     "Accepte une invitation et choisit le mot de passe",
+		RequestBody: public.Body("application/json", AcceptInvitationInput{}),
+		Responses:   map[string]*openapi.Response{"200": public.JSON("Compte activé", User{})},
+	})
+}
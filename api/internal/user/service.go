@@ -2,20 +2,96 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+
+	"test-api/kit/apperr"
+	"test-api/kit/auth"
+	"test-api/kit/logger"
 )
 
 type serviceImpl struct {
 	repo Repository
+
+	// deletedRetention est la durée pendant laquelle un utilisateur supprimé reste restaurable.
+	deletedRetention time.Duration
+
+	// Invitations : acheminement, signature des jetons, URL du lien et durée de validité.
+	notifier          Notifier
+	invitationKey     []byte
+	invitationBaseURL string
+	invitationTTL     time.Duration
+
+	now func() time.Time
+}
+
+// DefaultDeletedRetention est la durée de rétention par défaut des utilisateurs supprimés.
+const DefaultDeletedRetention = 90 * 24 * time.Hour
+
+// ServiceOption configure le service à la construction.
+type ServiceOption func(*serviceImpl)
+
+// WithDeletedRetention définit au bout de combien de temps un utilisateur supprimé est purgé définitivement.
+func WithDeletedRetention(d time.Duration) ServiceOption {
+	return func(s *serviceImpl) {
+		s.deletedRetention = d
+	}
+}
+
+// WithNotifier définit l'acheminement des invitations (LogNotifier par défaut).
+func WithNotifier(n Notifier) ServiceOption {
+	return func(s *serviceImpl) {
+		s.notifier = n
+	}
+}
+
+// WithInvitationSigningKey définit la clé HMAC des jetons d'invitation. Elle doit être partagée
+// par toutes les instances ; sans elle, une clé aléatoire est utilisée (invitations perdues au redémarrage).
+func WithInvitationSigningKey(key []byte) ServiceOption {
+	return func(s *serviceImpl) {
+		s.invitationKey = append([]byte(nil), key...)
+	}
+}
+
+// WithInvitationBaseURL définit le début du lien envoyé à l'invité, complété par le jeton
+// (ex : "https://erp.example.com/invitations/" ; le front appelle ensuite l'API d'acceptation).
+func WithInvitationBaseURL(baseURL string) ServiceOption {
+	return func(s *serviceImpl) {
+		s.invitationBaseURL = baseURL
+	}
+}
+
+// WithInvitationTTL définit la durée de validité des invitations.
+func WithInvitationTTL(d time.Duration) ServiceOption {
+	return func(s *serviceImpl) {
+		s.invitationTTL = d
+	}
+}
+
+// WithClock remplace l'horloge du service (suppression logique, expiration des invitations).
+func WithClock(now func() time.Time) ServiceOption {
+	return func(s *serviceImpl) {
+		s.now = now
+	}
 }
 
 // -- Définition des erreurs métier --
+// Chaque erreur enveloppe une catégorie de kit/apperr : c'est elle qui détermine le statut HTTP.
 
-var ErrUserNotFound = fmt.Errorf("user not found")
-var ErrEmailAlreadyExists = fmt.Errorf("email already registered for this tenant")
+var ErrUserNotFound = apperr.New(apperr.ErrNotFound, "user not found")
+var ErrEmailAlreadyExists = apperr.New(apperr.ErrConflict, "email already registered for this tenant")
+var ErrUserModified = apperr.New(apperr.ErrPreconditionFailed, "user has been modified since it was read")
+var ErrInvalidCredentials = apperr.New(apperr.ErrUnauthorized, "invalid email or password")
+var ErrInvalidStatusTransition = apperr.New(apperr.ErrConflict, "invalid account status transition")
 
 // ErrInvalidInput est une erreur générique de validation.
 type ErrInvalidInput struct {
@@ -27,29 +103,70 @@ func (e ErrInvalidInput) Error() string {
 	return fmt.Sprintf("invalid input for field '%s': %s", e.Field, e.Message)
 }
 
+// FieldErrors expose le détail pour le champ "errors" du document problem+json.
+func (e ErrInvalidInput) FieldErrors() []apperr.FieldError {
+	return []apperr.FieldError{{Field: e.Field, Message: e.Message}}
+}
+
+// PublicMessage : le détail de validation est destiné au client.
+func (e ErrInvalidInput) PublicMessage() string {
+	return e.Error()
+}
+
+// Unwrap rattache l'erreur à la catégorie apperr.ErrInvalidInput (HTTP 400).
+func (e ErrInvalidInput) Unwrap() error {
+	return apperr.ErrInvalidInput
+}
+
 // =================================================================================
 // Implémentation du Service
 // =================================================================================
 
-func NewService(r Repository) Service {
-	return &serviceImpl{
-		repo: r,
+func NewService(r Repository, opts ...ServiceOption) Service {
+	s := &serviceImpl{
+		repo:              r,
+		deletedRetention:  DefaultDeletedRetention,
+		notifier:          LogNotifier{},
+		invitationBaseURL: "/invitations/",
+		invitationTTL:     DefaultInvitationTTL,
+		now:               time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if len(s.invitationKey) == 0 {
+		s.invitationKey = make([]byte, 32)
+		if _, err := rand.Read(s.invitationKey); err != nil {
+			panic(fmt.Sprintf("user: cannot generate invitation signing key: %v", err))
+		}
 	}
+	return s
 }
 
 func (s *serviceImpl) CreateUser(ctx context.Context, tenantID string, input CreateUserInput) (*User, error) {
 	// 1. Nettoyage et validation de base des entrées
+	// On accumule toutes les erreurs pour que le formulaire puisse tout afficher d'un coup.
+	var errs []error
+
 	email := strings.ToLower(strings.TrimSpace(input.Email))
 	if email == "" {
-		return nil, ErrInvalidInput{Field: "email", Message: "cannot be empty"}
-	}
-	if !strings.Contains(email, "@") {
+		errs = append(errs, ErrInvalidInput{Field: "email", Message: "cannot be empty"})
+	} else if !strings.Contains(email, "@") {
 		// C'est une validation simpliste, utilisez une regex en prod
-		return nil, ErrInvalidInput{Field: "email", Message: "invalid format"}
+		errs = append(errs, ErrInvalidInput{Field: "email", Message: "invalid format"})
 	}
 
 	if strings.TrimSpace(input.Nom) == "" {
-		return nil, ErrInvalidInput{Field: "nom", Message: "cannot be empty"}
+		errs = append(errs, ErrInvalidInput{Field: "nom", Message: "cannot be empty"})
+	}
+
+	// Le mot de passe est facultatif, mais s'il est fourni il doit respecter la politique.
+	if input.Password != "" {
+		errs = append(errs, validatePassword(input.Password, email)...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 
 	// 2. Validation métier : Vérifier l'unicité de l'email dans ce tenant.
@@ -57,15 +174,8 @@ func (s *serviceImpl) CreateUser(ctx context.Context, tenantID string, input Cre
 	// ATTENTION : C'est une vérification "soft". Entre cet appel et l'insertion,
 	// une autre requête concurrente pourrait passer. C'est un compromis classique en NoSQL.
 	// La vraie unicité doit être gérée par la DB si possible (index unique composite tenantID+email sur Cosmos).
-	checkFilter := Filter{Email: &email, Limit: 1}
-	existingUsers, err := s.repo.Search(ctx, tenantID, checkFilter)
-	if err != nil {
-		// Si erreur technique DB, on remonte.
-		return nil, fmt.Errorf("failed to check existing email: %w", err)
-	}
-	if len(existingUsers) > 0 {
-		// Règle métier violée
-		return nil, ErrEmailAlreadyExists
+	if err := s.ensureEmailAvailable(ctx, tenantID, email); err != nil {
+		return nil, err
 	}
 
 	// 3. Enrichissement des données et création de l'entité finale
@@ -75,11 +185,21 @@ func (s *serviceImpl) CreateUser(ctx context.Context, tenantID string, input Cre
 		Email:    email,
 		Nom:      strings.TrimSpace(input.Nom),
 		Prenom:   strings.TrimSpace(input.Prenom),
+		Status:   StatusActive,
+		Roles:    []string{auth.RoleMember},
 		// Ici on ajouterait:
 		// CreatedAt: time.Now().UTC(),
 		// IsActive:  true,
 	}
 
+	if input.Password != "" {
+		hash, err := auth.HashPassword(input.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		newUser.PasswordHash = hash
+	}
+
 	// 4. Persistance via le repository
 	if err := s.repo.Create(ctx, newUser); err != nil {
 		return nil, fmt.Errorf("failed to create user in repo: %w", err)
@@ -89,8 +209,23 @@ func (s *serviceImpl) CreateUser(ctx context.Context, tenantID string, input Cre
 	return newUser, nil
 }
 
+// ensureEmailAvailable vérifie qu'aucun utilisateur du tenant n'utilise déjà cet email.
+func (s *serviceImpl) ensureEmailAvailable(ctx context.Context, tenantID string, email string) error {
+	checkFilter := Filter{Email: &email, Limit: 1}
+	existingUsers, _, err := s.repo.Search(ctx, tenantID, checkFilter)
+	if err != nil {
+		// Si erreur technique DB, on remonte.
+		return fmt.Errorf("failed to check existing email: %w", err)
+	}
+	if len(existingUsers) > 0 {
+		// Règle métier violée
+		return ErrEmailAlreadyExists
+	}
+	return nil
+}
+
 // GetUser implémente la logique de récupération simple.
-func (s *serviceImpl) GetUser(ctx context.Context, tenantID string, id string) (*User, error) {
+func (s *serviceImpl) GetUser(ctx context.Context, tenantID string, id string, includeDeleted bool) (*User, error) {
 	// Validation simple de l'ID
 	if _, err := uuid.Parse(id); err != nil {
 		return nil, ErrInvalidInput{Field: "id", Message: "invalid UUID format"}
@@ -119,11 +254,16 @@ func (s *serviceImpl) GetUser(ctx context.Context, tenantID string, id string) (
 		return nil, ErrUserNotFound
 	}
 
+	// Un utilisateur supprimé logiquement n'existe plus pour le reste de l'application.
+	if user.IsDeleted() && !includeDeleted {
+		return nil, ErrUserNotFound
+	}
+
 	return user, nil
 }
 
 // SearchUsers implémente la logique de recherche.
-func (s *serviceImpl) SearchUsers(ctx context.Context, tenantID string, filter Filter) ([]User, error) {
+func (s *serviceImpl) SearchUsers(ctx context.Context, tenantID string, filter Filter) ([]User, string, error) {
 	// Ici, on pourrait appliquer des règles métier sur le filtre.
 	// Par exemple, forcer une limite max si elle n'est pas fournie pour éviter de tuer la DB.
 	if filter.Limit == 0 {
@@ -133,29 +273,370 @@ func (s *serviceImpl) SearchUsers(ctx context.Context, tenantID string, filter F
 		filter.Limit = 100 // Hard cap métier
 	}
 
-	// Appel au repository
-	users, err := s.repo.Search(ctx, tenantID, filter)
+	// Appel au repository (le jeton de continuation est transmis tel quel)
+	users, next, err := s.repo.Search(ctx, tenantID, filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search users: %w", err)
+		return nil, "", fmt.Errorf("failed to search users: %w", err)
 	}
 
 	// Pas besoin de défense en profondeur sur le tenantID ici car le repo est censé
 	// appliquer la clause "WHERE tenantId = X" sur toute la liste.
 
-	return users, nil
+	return users, next, nil
+}
+
+// UpdateUser applique les champs fournis (non-nil) sur l'utilisateur existant.
+func (s *serviceImpl) UpdateUser(ctx context.Context, tenantID string, id string, input UpdateUserInput, ifMatch string) (*User, error) {
+	// 1. GetUser vérifie l'ID, l'existence et le tenant (un utilisateur supprimé n'est pas modifiable).
+	user, err := s.GetUser(ctx, tenantID, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Contrôle de version : le client a peut-être une copie périmée.
+	if err := checkVersion(user, ifMatch); err != nil {
+		return nil, err
+	}
+
+	// 3. Application des modifications
+	var errs []error
+
+	emailChanged := false
+	if input.Email != nil {
+		// Même normalisation qu'à la création.
+		email := strings.ToLower(strings.TrimSpace(*input.Email))
+		if email == "" {
+			errs = append(errs, ErrInvalidInput{Field: "email", Message: "cannot be empty"})
+		} else if !strings.Contains(email, "@") {
+			errs = append(errs, ErrInvalidInput{Field: "email", Message: "invalid format"})
+		}
+		emailChanged = email != user.Email
+		user.Email = email
+	}
+	if input.Nom != nil {
+		if strings.TrimSpace(*input.Nom) == "" {
+			errs = append(errs, ErrInvalidInput{Field: "nom", Message: "cannot be empty"})
+		}
+		user.Nom = strings.TrimSpace(*input.Nom)
+	}
+	if input.Prenom != nil {
+		user.Prenom = strings.TrimSpace(*input.Prenom)
+	}
+	if input.Status != nil && *input.Status != user.EffectiveStatus() {
+		switch *input.Status {
+		case StatusActive, StatusDisabled:
+			// Un invité n'est activé qu'en acceptant l'invitation (il n'a pas encore de mot de passe).
+			if user.EffectiveStatus() == StatusInvited && *input.Status == StatusActive {
+				return nil, fmt.Errorf("%w: an invited account is activated by accepting its invitation", ErrInvalidStatusTransition)
+			}
+			user.Status = *input.Status
+		default:
+			errs = append(errs, ErrInvalidInput{Field: "status", Message: "must be 'active' or 'disabled'"})
+		}
+	}
+
+	if input.Roles != nil {
+		roles, err := normalizeRoles(*input.Roles)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		user.Roles = roles
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// 4. Unicité de l'email dans le tenant (même compromis "soft" qu'à la création).
+	if emailChanged {
+		if err := s.ensureEmailAvailable(ctx, tenantID, user.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Persistance : une écriture concurrente depuis la lecture donne database.ErrPreconditionFailed (412).
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user in repo: %w", err)
+	}
+
+	return user, nil
 }
 
-func (s *serviceImpl) UpdateUser(ctx context.Context, tenantID string, id string, input UpdateUserInput) (*User, error) {
-	// TODO: Implémenter
-	// 1. GetUser(id) pour vérifier qu'il existe et qu'on est dans le bon tenant.
-	// 2. Appliquer les modifications des champs non-nil de l'input sur l'entité récupérée.
-	// 3. repo.Update(userUpdated)
-	return nil, fmt.Errorf("not implemented")
+// normalizeRoles nettoie une liste de rôles (espaces, doublons). Un compte a au moins un rôle ;
+// les noms ne sont pas limités aux rôles standards, un tenant pouvant définir les siens (auth.Policy).
+func normalizeRoles(roles []string) ([]string, error) {
+	var out []string
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			return nil, ErrInvalidInput{Field: "roles", Message: "cannot contain an empty role"}
+		}
+		if !slices.Contains(out, role) {
+			out = append(out, role)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrInvalidInput{Field: "roles", Message: "cannot be empty"}
+	}
+	return out, nil
 }
 
-func (s *serviceImpl) DeleteUser(ctx context.Context, tenantID string, id string) error {
-	// TODO: Implémenter
-	// Validation UUID
-	// repo.Delete(...)
-	return fmt.Errorf("not implemented")
+// checkVersion compare l'ETag attendu par le client (If-Match, vide = pas de contrôle) à la version lue.
+// La vraie garantie vient de la base (le repo écrit avec l'ETag lu), ceci évite juste un aller-retour.
+func checkVersion(user *User, ifMatch string) error {
+	if ifMatch != "" && ifMatch != user.ETag {
+		return ErrUserModified
+	}
+	return nil
+}
+
+// DeleteUser supprime logiquement l'utilisateur : il est masqué, reste restaurable pendant
+// la période de rétention, puis purgé définitivement par la base (TTL).
+func (s *serviceImpl) DeleteUser(ctx context.Context, tenantID string, id string, deletedBy string, ifMatch string) error {
+	user, err := s.GetUser(ctx, tenantID, id, false)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(user, ifMatch); err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	user.DeletedAt = &now
+	user.DeletedBy = deletedBy
+	user.TTL = int(s.deletedRetention.Seconds())
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to soft-delete user in repo: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreUser annule la suppression logique. Sans effet si l'utilisateur n'est pas supprimé.
+func (s *serviceImpl) RestoreUser(ctx context.Context, tenantID string, id string, ifMatch string) (*User, error) {
+	user, err := s.GetUser(ctx, tenantID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsDeleted() {
+		return user, nil
+	}
+
+	if err := checkVersion(user, ifMatch); err != nil {
+		return nil, err
+	}
+
+	// Pendant la suppression, l'email a pu être réattribué à un autre utilisateur du tenant.
+	if err := s.ensureEmailAvailable(ctx, tenantID, user.Email); err != nil {
+		return nil, err
+	}
+
+	user.DeletedAt = nil
+	user.DeletedBy = ""
+	user.TTL = 0
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to restore user in repo: %w", err)
+	}
+
+	return user, nil
+}
+
+// =================================================================================
+// Authentification locale
+// =================================================================================
+
+// Politique de mot de passe (NIST SP 800-63B) : la longueur prime sur les règles de composition.
+const (
+	MinPasswordLength = 12
+	// MaxPasswordLength borne le coût du hachage (un mot de passe de plusieurs Mo serait un déni de service).
+	MaxPasswordLength = 128
+)
+
+// validatePassword retourne les violations de la politique de mot de passe.
+func validatePassword(password string, email string) []error {
+	var errs []error
+
+	length := utf8.RuneCountInString(password)
+	if length < MinPasswordLength {
+		errs = append(errs, ErrInvalidInput{Field: "password", Message: fmt.Sprintf("must be at least %d characters", MinPasswordLength)})
+	}
+	if length > MaxPasswordLength {
+		errs = append(errs, ErrInvalidInput{Field: "password", Message: fmt.Sprintf("must be at most %d characters", MaxPasswordLength)})
+	}
+	if first, _ := utf8.DecodeRuneInString(password); strings.Trim(password, string(first)) == "" {
+		errs = append(errs, ErrInvalidInput{Field: "password", Message: "cannot be a single repeated character"})
+	}
+	if local, _, _ := strings.Cut(email, "@"); len(local) >= 3 && strings.Contains(strings.ToLower(password), local) {
+		errs = append(errs, ErrInvalidInput{Field: "password", Message: "cannot contain the email address"})
+	}
+	return errs
+}
+
+// dummyPasswordHash sert à vérifier un mot de passe même quand le compte n'existe pas :
+// le temps de réponse ne révèle pas quels emails sont inscrits.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := auth.HashPassword("dummy-password-for-timing")
+	return hash
+})
+
+func (s *serviceImpl) VerifyCredentials(ctx context.Context, tenantID string, email string, password string) (*User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// La recherche exclut les utilisateurs supprimés : ils ne peuvent plus se connecter.
+	users, _, err := s.repo.Search(ctx, tenantID, Filter{Email: &email, Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+	// Invités (pas encore de mot de passe) et comptes désactivés ne peuvent pas se connecter.
+	if len(users) == 0 || users[0].PasswordHash == "" || users[0].EffectiveStatus() != StatusActive {
+		_, _, _ = auth.VerifyPassword(dummyPasswordHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	user := &users[0]
+
+	ok, needsRehash, err := auth.VerifyPassword(user.PasswordHash, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Migration transparente des anciennes empreintes (bcrypt importé, paramètres argon2 durcis).
+	if needsRehash {
+		if hash, err := auth.HashPassword(password); err == nil {
+			user.PasswordHash = hash
+			if err := s.repo.Update(ctx, user); err != nil {
+				logger.Warn(ctx, "Failed to upgrade password hash", "userID", user.ID, "error", err)
+			}
+		}
+	}
+
+	return user, nil
+}
+
+// =================================================================================
+// Invitations
+// =================================================================================
+
+// InviteUser crée le compte en attente puis envoie l'invitation. Si l'envoi échoue, le compte
+// est supprimé : l'email reste disponible et l'invitation peut simplement être relancée.
+func (s *serviceImpl) InviteUser(ctx context.Context, tenantID string, input InviteUserInput) (*User, error) {
+	var errs []error
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == "" {
+		errs = append(errs, ErrInvalidInput{Field: "email", Message: "cannot be empty"})
+	} else if !strings.Contains(email, "@") {
+		errs = append(errs, ErrInvalidInput{Field: "email", Message: "invalid format"})
+	}
+	if strings.TrimSpace(input.Nom) == "" {
+		errs = append(errs, ErrInvalidInput{Field: "nom", Message: "cannot be empty"})
+	}
+	roles := []string{auth.RoleMember}
+	if input.Roles != nil {
+		var err error
+		if roles, err = normalizeRoles(input.Roles); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if err := s.ensureEmailAvailable(ctx, tenantID, email); err != nil {
+		return nil, err
+	}
+
+	nonce, err := newInvitationNonce()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := s.now().Add(s.invitationTTL).UTC().Truncate(time.Second)
+
+	invited := &User{
+		TenantID:            tenantID,
+		ID:                  uuid.New().String(),
+		Email:               email,
+		Nom:                 strings.TrimSpace(input.Nom),
+		Prenom:              strings.TrimSpace(input.Prenom),
+		Status:              StatusInvited,
+		Roles:               roles,
+		InvitationNonceHash: hashNonce(nonce),
+		InvitationExpiresAt: &expiresAt,
+	}
+	if err := s.repo.Create(ctx, invited); err != nil {
+		return nil, fmt.Errorf("failed to create invited user in repo: %w", err)
+	}
+
+	token := signInvitation(s.invitationKey, invitationClaims{
+		TenantID:  tenantID,
+		UserID:    invited.ID,
+		Nonce:     nonce,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	err = s.notifier.SendInvitation(ctx, Invitation{
+		TenantID:  tenantID,
+		UserID:    invited.ID,
+		Email:     invited.Email,
+		Nom:       invited.Nom,
+		Prenom:    invited.Prenom,
+		Link:      s.invitationBaseURL + token,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		if delErr := s.repo.Delete(ctx, tenantID, invited.ID, ""); delErr != nil {
+			logger.Error(ctx, "Failed to roll back invited user", "userID", invited.ID, "error", delErr)
+		}
+		return nil, fmt.Errorf("failed to send invitation: %w", err)
+	}
+
+	return invited, nil
+}
+
+// AcceptInvitation vérifie le jeton, applique la politique de mot de passe et active le compte.
+func (s *serviceImpl) AcceptInvitation(ctx context.Context, token string, input AcceptInvitationInput) (*User, error) {
+	claims, err := parseInvitation(s.invitationKey, token, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, claims.TenantID, claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invited user: %w", err)
+	}
+	// Compte supprimé ou déjà activé : l'invitation n'est plus valable.
+	if user == nil || user.IsDeleted() || user.EffectiveStatus() != StatusInvited ||
+		subtle.ConstantTimeCompare([]byte(user.InvitationNonceHash), []byte(hashNonce(claims.Nonce))) != 1 {
+		return nil, ErrInvalidInvitation
+	}
+
+	if input.Password == "" {
+		return nil, ErrInvalidInput{Field: "password", Message: "cannot be empty"}
+	}
+	if errs := validatePassword(input.Password, user.Email); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = hash
+	user.Status = StatusActive
+	user.InvitationNonceHash = ""
+	user.InvitationExpiresAt = nil
+
+	// L'ETag lu plus haut garantit l'usage unique même en cas d'acceptations simultanées.
+	if err := s.repo.Update(ctx, user); err != nil {
+		if errors.Is(err, apperr.ErrPreconditionFailed) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, fmt.Errorf("failed to activate invited user in repo: %w", err)
+	}
+
+	return user, nil
 }
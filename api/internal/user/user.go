@@ -2,6 +2,9 @@ package user
 
 import (
 	"context"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // =================================================================================
@@ -19,12 +22,70 @@ type User struct {
 	Nom    string `json:"nom"`
 	Prenom string `json:"prenom"`
 
+	// Suppression logique : un utilisateur référencé par des factures ou des pistes d'audit
+	// ne peut pas disparaître. DeletedAt non nil = supprimé (masqué par défaut).
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy string     `json:"deletedBy,omitempty"`
+
+	// TTL (secondes) est posé lors de la suppression logique : la base purge définitivement le
+	// document à l'issue de la période de rétention (Time To Live Cosmos, activé sur le conteneur
+	// avec DefaultTimeToLive = -1). Retiré lors d'une restauration.
+	TTL int `json:"ttl,omitempty"`
+
+	// ETag est la version du document, gérée par la base (concurrence optimiste).
+	// Exposé au client via l'en-tête HTTP ETag, à renvoyer en If-Match lors des écritures.
+	ETag string `json:"_etag,omitempty"`
+
+	// Status est l'état du compte (StatusInvited, StatusActive, StatusDisabled).
+	// Vide pour les comptes antérieurs à son introduction : équivaut à StatusActive.
+	Status string `json:"status,omitempty" enum:"invited,active,disabled"`
+
+	// Invitation en attente : empreinte du nonce du jeton (usage unique) et date limite.
+	InvitationNonceHash string     `json:"invitationNonceHash,omitempty" openapi:"-"`
+	InvitationExpiresAt *time.Time `json:"invitationExpiresAt,omitempty"`
+
+	// Roles sont les rôles du compte dans le tenant, repris dans les jetons émis à la connexion
+	// (voir auth.DefaultRoles). Un nouvel utilisateur est auth.RoleMember.
+	Roles []string `json:"roles,omitempty"`
+
+	// PasswordHash est l'empreinte argon2id (ou bcrypt importé) du mot de passe, vide pour un
+	// compte sans connexion locale. Stockée en base mais jamais renvoyée au client (voir Public).
+	PasswordHash string `json:"passwordHash,omitempty" openapi:"-"`
+
 	// Vous ajouterez sûrement ici plus tard :
-	// HashedPassword string `json:"-"` // Le "-" évite de le renvoyer dans le JSON
 	// CreatedAt      time.Time `json:"createdAt"`
 	// IsActive       bool      `json:"isActive"`
 }
 
+// États du compte.
+const (
+	// StatusInvited : invitation envoyée, le compte n'a pas encore d'identifiants.
+	StatusInvited = "invited"
+	StatusActive  = "active"
+	// StatusDisabled : connexion refusée, le compte est conservé.
+	StatusDisabled = "disabled"
+)
+
+// Public retourne la représentation exposée par l'API : sans les secrets d'authentification.
+func (u User) Public() User {
+	u.PasswordHash = ""
+	u.InvitationNonceHash = ""
+	return u
+}
+
+// EffectiveStatus retourne l'état du compte, StatusActive pour les comptes sans état.
+func (u User) EffectiveStatus() string {
+	if u.Status == "" {
+		return StatusActive
+	}
+	return u.Status
+}
+
+// IsDeleted indique si l'utilisateur a été supprimé logiquement.
+func (u User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
 // GetID retourne l'identifiant unique.
 func (u User) GetID() string {
 	return u.ID
@@ -35,6 +96,16 @@ func (u User) GetTenantID() string {
 	return u.TenantID
 }
 
+// GetETag retourne la version du document (implémente database.Versioned).
+func (u User) GetETag() string {
+	return u.ETag
+}
+
+// SetETag est appelé par l'adapteur après chaque lecture/écriture.
+func (u *User) SetETag(etag string) {
+	u.ETag = etag
+}
+
 // ---------------------------------------------------------------------------------
 // Modèles d'Entrée (Input DTOs)
 // Ces structures servent à valider les données entrant dans votre API.
@@ -46,34 +117,75 @@ type CreateUserInput struct {
 	Email  string `json:"email"`
 	Nom    string `json:"nom"`
 	Prenom string `json:"prenom"`
-	// Password string `json:"password"`
+	// Password est facultatif : sans lui, le compte ne peut pas se connecter localement
+	// (authentification Entra ID, ou invitation à compléter).
+	Password string `json:"password,omitempty"`
+}
+
+// InviteUserInput définit les données d'une invitation : le compte est créé sans mot de passe,
+// l'invité le choisit en acceptant l'invitation.
+type InviteUserInput struct {
+	Email  string `json:"email"`
+	Nom    string `json:"nom"`
+	Prenom string `json:"prenom"`
+	// Roles sont les rôles du compte (auth.RoleMember si absent). Réservé à PermissionAdmin.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// AcceptInvitationInput est le corps de POST /auth/invitations/{token}/accept.
+type AcceptInvitationInput struct {
+	Password string `json:"password"`
 }
 
 // UpdateUserInput définit les champs modifiables d'un utilisateur.
 // L'utilisation de pointeurs (*) permet de savoir si un champ a été fourni ou non (pour faire du PATCH).
+// Un null JSON Merge Patch est traduit en chaîne vide (le service décide si c'est autorisé).
+// id, tenantID et _etag ne sont volontairement pas modifiables.
 type UpdateUserInput struct {
 	Email  *string `json:"email,omitempty"`
 	Nom    *string `json:"nom,omitempty"`
 	Prenom *string `json:"prenom,omitempty"`
+	// Status permet d'activer ou de désactiver un compte (pas de revenir à "invited").
+	Status *string `json:"status,omitempty" enum:"active,disabled"`
+	// Roles remplace les rôles du compte. Réservé à PermissionAdmin.
+	Roles *[]string `json:"roles,omitempty"`
 }
 
 // Filter définit les critères de recherche pour la méthode Search.
 type Filter struct {
 	// Pointeurs pour distinguer la recherche d'une chaîne vide vs pas de filtre sur ce champ
-	Email *string
-	Nom   *string
+	Email  *string
+	Nom    *string
+	Status *string
 
-	// Pagination
-	Offset int
-	Limit  int
-}
+	// IncludeDeleted inclut les utilisateurs supprimés logiquement (option d'administration).
+	IncludeDeleted bool
 
-// TODO Valider qu'il s'agit d'une bonne pratique en go
-// TenantIDContextKey est la clé publique utilisée pour passer le tenantID dans le contexte.
-// Le middleware d'auth écrira avec cette clé, le handler lira avec cette clé, et le test injectera avec cette clé.
-type contextKey string
+	// Pagination par curseur : Limit est la taille de page, Continuation le jeton brut
+	// de la page précédente (déjà vérifié par le handler, jamais exposé au client).
+	Limit        int
+	Continuation string
+}
 
-const TenantIDContextKey contextKey = "x-tenant-id"
+// bindingKey est la représentation canonique des critères (hors pagination) :
+// un curseur n'est valable que pour les critères qui l'ont produit. Les valeurs sont échappées,
+// sans quoi nom="a&status=b" et (nom="a", status="b") donneraient la même clé.
+func (f Filter) bindingKey() string {
+	key := url.Values{
+		"limit":          {strconv.Itoa(f.Limit)},
+		"includeDeleted": {strconv.FormatBool(f.IncludeDeleted)},
+	}
+	if f.Email != nil {
+		key.Set("email", *f.Email)
+	}
+	if f.Nom != nil {
+		key.Set("nom", *f.Nom)
+	}
+	if f.Status != nil {
+		key.Set("status", *f.Status)
+	}
+	return key.Encode()
+}
 
 // =================================================================================
 // Interfaces (Contrats)
@@ -83,19 +195,36 @@ const TenantIDContextKey contextKey = "x-tenant-id"
 // Service définit le contrat de la couche métier (Business Logic).
 type Service interface {
 	CreateUser(ctx context.Context, tenantID string, input CreateUserInput) (*User, error)
-	GetUser(ctx context.Context, tenantID string, id string) (*User, error)
-	UpdateUser(ctx context.Context, tenantID string, id string, input UpdateUserInput) (*User, error)
-	DeleteUser(ctx context.Context, tenantID string, id string) error
-
-	SearchUsers(ctx context.Context, tenantID string, filter Filter) ([]User, error)
+	// GetUser masque les utilisateurs supprimés, sauf si includeDeleted est vrai.
+	GetUser(ctx context.Context, tenantID string, id string, includeDeleted bool) (*User, error)
+	// ifMatch est l'ETag attendu (en-tête If-Match) ; vide = pas de contrôle de version.
+	UpdateUser(ctx context.Context, tenantID string, id string, input UpdateUserInput, ifMatch string) (*User, error)
+	// DeleteUser supprime logiquement l'utilisateur ; deletedBy est l'identifiant de l'auteur.
+	DeleteUser(ctx context.Context, tenantID string, id string, deletedBy string, ifMatch string) error
+	// RestoreUser annule une suppression logique (tant que la rétention n'est pas écoulée).
+	RestoreUser(ctx context.Context, tenantID string, id string, ifMatch string) (*User, error)
+
+	// InviteUser crée un compte en attente et envoie l'invitation via le Notifier.
+	InviteUser(ctx context.Context, tenantID string, input InviteUserInput) (*User, error)
+	// AcceptInvitation consomme le jeton d'invitation, enregistre le mot de passe et active le compte.
+	AcceptInvitation(ctx context.Context, token string, input AcceptInvitationInput) (*User, error)
+
+	// VerifyCredentials authentifie un compte local par email et mot de passe.
+	// Toute erreur d'identification donne ErrInvalidCredentials, sans préciser la cause.
+	VerifyCredentials(ctx context.Context, tenantID string, email string, password string) (*User, error)
+
+	// SearchUsers renvoie une page d'utilisateurs et le jeton de continuation de la suivante ("" = fin).
+	SearchUsers(ctx context.Context, tenantID string, filter Filter) ([]User, string, error)
 }
 
 // Repository définit le contrat pour la couche de persistance (Base de données).
+// Create et Update renseignent le nouvel ETag sur l'utilisateur passé en paramètre.
+// Update et Delete échouent avec database.ErrPreconditionFailed si l'ETag ne correspond plus.
 type Repository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, tenantID string, id string) (*User, error)
 	Update(ctx context.Context, user *User) error
-	Delete(ctx context.Context, tenantID string, id string) error
+	Delete(ctx context.Context, tenantID string, id string, etag string) error
 
-	Search(ctx context.Context, tenantID string, filter Filter) ([]User, error)
+	Search(ctx context.Context, tenantID string, filter Filter) ([]User, string, error)
 }
@@ -6,13 +6,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"sync"
+	"strings"
 	"testing"
+	"time"
 
 	"test-api/internal/user"
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+	"test-api/kit/auth"
+	"test-api/kit/database"
+	"test-api/kit/database/memory"
+	"test-api/kit/pagination"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -21,30 +29,31 @@ import (
 )
 
 // =====================================================================================
-// TEST DE COMPOSANT (Handler -> Service -> Fake Repo)
+// TEST DE COMPOSANT (Handler -> Service -> Repository -> Base en mémoire)
 // =====================================================================================
 
 func TestCreateUser_Flow(t *testing.T) {
 	// 1. SETUP
-	fakeRepo := newFakeUserRepository()
-	svc := user.NewService(fakeRepo)
-	handler := user.NewHandler(svc)
+	adapter, repo := newTestRepository()
+	svc := user.NewService(repo)
+	handler := newTestHandler(svc)
 
 	// Données de test
 	const testTenantID = "tenant-123"
 	emailToCreate := "arthur@kaamelott.com"
 
 	reqBody := map[string]string{
-		"email":  emailToCreate,
-		"nom":    "Pendragon",
-		"prenom": "Arthur",
+		"email":    emailToCreate,
+		"nom":      "Pendragon",
+		"prenom":   "Arthur",
+		"password": "Excalibur-du-Lac-2024",
 	}
 	reqBytes, _ := json.Marshal(reqBody)
 
 	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(reqBytes))
 	req.Header.Set("Content-Type", "application/json")
 
-	ctx := context.WithValue(req.Context(), user.TenantIDContextKey, testTenantID)
+	ctx := auth.WithPrincipal(req.Context(), testPrincipal(testTenantID))
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -66,25 +75,69 @@ func TestCreateUser_Flow(t *testing.T) {
 	assert.Equal(t, emailToCreate, respUser.Email)
 	// On vérifie que le tenantID est bien conservé
 	assert.Equal(t, testTenantID, respUser.TenantID)
+	// L'empreinte du mot de passe n'est jamais renvoyée
+	assert.Empty(t, respUser.PasswordHash)
 
-	// 5. ASSERTIONS SUR L'ÉTAT (Fake DB)
-	fakeRepo.mu.RLock()
-	defer fakeRepo.mu.RUnlock()
-
-	require.Equal(t, 1, len(fakeRepo.data))
+	// 5. ASSERTIONS SUR L'ÉTAT (Base en mémoire)
+	page, err := adapter.Search(context.Background(), database.NewQuery[user.User](), testTenantID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(page.Items))
 
-	// On reconstruit la clé composée pour vérifier la présence
-	storageKey := makeKey(testTenantID, respUser.ID)
-	storedUser, exists := fakeRepo.data[storageKey]
+	// On relit dans la partition du tenant pour vérifier la présence
+	storedUser, err := adapter.Read(context.Background(), respUser.ID, testTenantID)
 
-	require.True(t, exists, "L'utilisateur doit être trouvé avec la clé tenant#id")
+	require.NoError(t, err, "L'utilisateur doit être trouvé dans la partition du tenant")
 	assert.Equal(t, emailToCreate, storedUser.Email)
+	assert.NotEmpty(t, storedUser.PasswordHash, "Seule l'empreinte est stockée")
+	assert.NotContains(t, storedUser.PasswordHash, "Excalibur")
+	assert.Equal(t, []string{auth.RoleMember}, storedUser.Roles)
 }
+
+func TestVerifyCredentials(t *testing.T) {
+	const tenantA = "tenant-A"
+	_, repo := newTestRepository()
+	svc := user.NewService(repo)
+
+	created, err := svc.CreateUser(context.Background(), tenantA, user.CreateUserInput{
+		Email: "Arthur@Kaamelott.com", Nom: "Pendragon", Password: "Excalibur-du-Lac-2024",
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateUser(context.Background(), tenantA, user.CreateUserInput{Email: "leodagan@kaamelott.com", Nom: "De Carmélide"})
+	require.NoError(t, err)
+
+	u, err := svc.VerifyCredentials(context.Background(), tenantA, " arthur@kaamelott.com", "Excalibur-du-Lac-2024")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, u.ID)
+
+	tests := []struct {
+		name     string
+		tenantID string
+		email    string
+		password string
+	}{
+		{"Mauvais mot de passe", tenantA, "arthur@kaamelott.com", "excalibur-du-lac-2024"},
+		{"Email inconnu", tenantA, "merlin@kaamelott.com", "Excalibur-du-Lac-2024"},
+		{"Autre tenant", "tenant-B", "arthur@kaamelott.com", "Excalibur-du-Lac-2024"},
+		{"Compte sans mot de passe", tenantA, "leodagan@kaamelott.com", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.VerifyCredentials(context.Background(), tc.tenantID, tc.email, tc.password)
+			assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+		})
+	}
+
+	// Un utilisateur supprimé ne peut plus se connecter.
+	require.NoError(t, svc.DeleteUser(context.Background(), tenantA, created.ID, "admin", ""))
+	_, err = svc.VerifyCredentials(context.Background(), tenantA, "arthur@kaamelott.com", "Excalibur-du-Lac-2024")
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+}
+
 func TestGetUser_Flow(t *testing.T) {
 	// 1. SETUP
-	fakeRepo := newFakeUserRepository()
-	svc := user.NewService(fakeRepo)
-	handler := user.NewHandler(svc)
+	adapter, repo := newTestRepository()
+	svc := user.NewService(repo)
+	handler := newTestHandler(svc)
 
 	const testTenantID = "tenant-456"
 	testUserID := uuid.NewString()
@@ -98,7 +151,7 @@ func TestGetUser_Flow(t *testing.T) {
 		Nom:      "De Carmelide",
 		Prenom:   "Léodagan",
 	}
-	fakeRepo.data[makeKey(testTenantID, testUserID)] = existingUser
+	seed(t, adapter, existingUser)
 
 	// --- CORRECTION ICI : ON SETUP UN ROUTEUR CHI ---
 	// On crée un mini-routeur juste pour ce test afin que chi.URLParam fonctionne.
@@ -113,7 +166,7 @@ func TestGetUser_Flow(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, targetURL, nil)
 
 	// Injection du TenantID dans le contexte (simulation du middleware auth)
-	ctx := context.WithValue(req.Context(), user.TenantIDContextKey, testTenantID)
+	ctx := auth.WithPrincipal(req.Context(), testPrincipal(testTenantID))
 	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
@@ -140,118 +193,661 @@ func TestGetUser_Flow(t *testing.T) {
 }
 
 // =====================================================================================
-// IMPLEMENTATION DU FAKE REPOSITORY (COMPATIBLE MULTI-TENANT)
+// SCÉNARIOS D'ERREUR HTTP (mapping des erreurs métier vers les statuts)
 // =====================================================================================
 
-type fakeUserRepository struct {
-	mu sync.RWMutex
-	// La clé de la map est une combinaison "tenantID#userID"
-	data map[string]user.User
+func TestUserErrors_Scenarios(t *testing.T) {
+	const tenantA = "tenant-A"
+	arthurID := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		tenantID       string
+		expectedStatus int
+	}{
+		{"JSON invalide", http.MethodPost, "/users", `{"email": "a@b.c",}`, tenantA, http.StatusBadRequest},
+		{"Email vide", http.MethodPost, "/users", `{"email": "", "nom": "Pendragon"}`, tenantA, http.StatusBadRequest},
+		{"Email déjà utilisé", http.MethodPost, "/users", `{"email": "arthur@kaamelott.com", "nom": "Pendragon"}`, tenantA, http.StatusConflict},
+		{"Mot de passe trop court", http.MethodPost, "/users", `{"email": "perceval@kaamelott.com", "nom": "De Galles", "password": "court"}`, tenantA, http.StatusBadRequest},
+		{"Mot de passe contenant l'email", http.MethodPost, "/users", `{"email": "perceval@kaamelott.com", "nom": "De Galles", "password": "Perceval-2024!"}`, tenantA, http.StatusBadRequest},
+		{"Tenant manquant", http.MethodPost, "/users", `{"email": "perceval@kaamelott.com", "nom": "De Galles"}`, "", http.StatusUnauthorized},
+		{"ID mal formé", http.MethodGet, "/users/pas-un-uuid", "", tenantA, http.StatusBadRequest},
+		{"Utilisateur inexistant", http.MethodGet, "/users/" + uuid.NewString(), "", tenantA, http.StatusNotFound},
+		{"Isolation inter-tenant", http.MethodGet, "/users/" + arthurID, "", "tenant-B", http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, repo := newTestRepository()
+			seed(t, adapter, user.User{
+				ID:       arthurID,
+				TenantID: tenantA,
+				Email:    "arthur@kaamelott.com",
+				Nom:      "Pendragon",
+			})
+			handler := newTestHandler(user.NewService(repo))
+
+			r := chi.NewRouter()
+			r.Route("/users", handler.RegisterRoutes)
+
+			req := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body))
+			if tc.tenantID != "" {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), testPrincipal(tc.tenantID)))
+			}
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			assert.NotContains(t, rr.Body.String(), "invalid character", "Les détails techniques ne doivent pas fuiter")
+		})
+	}
 }
 
-func newFakeUserRepository() *fakeUserRepository {
-	return &fakeUserRepository{
-		data: make(map[string]user.User),
+func TestUserRoutes_Permissions(t *testing.T) {
+	const tenantA = "tenant-A"
+	_, repo := newTestRepository()
+	handler := newTestHandler(user.NewService(repo))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	policy := auth.NewPolicy(auth.DefaultRoles)
+	// Ce tenant retire le droit de lecture aux simples membres.
+	policy.SetTenantRoles("tenant-restricted", auth.RoleMapping{auth.RoleMember: {}})
+
+	tests := []struct {
+		name           string
+		tenantID       string
+		role           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{"Membre : lecture autorisée", tenantA, auth.RoleMember, http.MethodGet, "", http.StatusOK},
+		{"Membre : écriture interdite", tenantA, auth.RoleMember, http.MethodPost, `{"email": "a@b.c", "nom": "A"}`, http.StatusForbidden},
+		{"Manager : écriture autorisée", tenantA, auth.RoleManager, http.MethodPost, `{"email": "a@b.c", "nom": "A"}`, http.StatusCreated},
+		{"Rôle inconnu", tenantA, "guest", http.MethodGet, "", http.StatusForbidden},
+		{"Correspondance propre au tenant", "tenant-restricted", auth.RoleMember, http.MethodGet, "", http.StatusForbidden},
 	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			principal := &auth.Principal{Subject: "u-1", TenantID: tc.tenantID, Roles: []string{tc.role}}
+			principal.Permissions = policy.Permissions(tc.tenantID, principal.Roles)
+
+			req := httptest.NewRequest(tc.method, "/users", bytes.NewBufferString(tc.body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			if tc.expectedStatus == http.StatusForbidden {
+				assert.Equal(t, api.ProblemContentType, rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestCreateUser_ProblemDetails(t *testing.T) {
+	_, repo := newTestRepository()
+	handler := newTestHandler(user.NewService(repo))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"email": "pas-un-email", "nom": " "}`))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), testPrincipal("tenant-123")))
+	rr := httptest.NewRecorder()
+
+	handler.Create(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var problem api.Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "/problems/invalid-input", problem.Type)
+	// Toutes les erreurs de champ sont remontées, pas seulement la première.
+	assert.ElementsMatch(t, []apperr.FieldError{
+		{Field: "email", Message: "invalid format"},
+		{Field: "nom", Message: "cannot be empty"},
+	}, problem.Errors)
 }
 
-// Helper pour créer la clé composée
-func makeKey(tenantID, id string) string {
-	return tenantID + "#" + id
+// =====================================================================================
+// CONCURRENCE OPTIMISTE (ETag / If-Match)
+// =====================================================================================
+
+func TestUpdateUser_ETag(t *testing.T) {
+	const testTenantID = "tenant-789"
+	_, repo := newTestRepository()
+	handler := newTestHandler(user.NewService(repo))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	do := func(method, target, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req = req.WithContext(auth.WithPrincipal(req.Context(), testPrincipal(testTenantID)))
+		if method == http.MethodPatch {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Création : l'ETag est exposé dans l'en-tête.
+	created := do(http.MethodPost, "/users", `{"email": "karadoc@kaamelott.com", "nom": "De Vannes"}`, "")
+	require.Equal(t, http.StatusCreated, created.Code)
+	var u user.User
+	require.NoError(t, json.NewDecoder(created.Body).Decode(&u))
+	firstETag := created.Header().Get("ETag")
+	require.NotEmpty(t, firstETag)
+
+	// Première modification avec le bon ETag : OK, et l'ETag change.
+	updated := do(http.MethodPatch, "/users/"+u.ID, `{"prenom": "Karadoc"}`, firstETag)
+	require.Equal(t, http.StatusOK, updated.Code, updated.Body.String())
+	assert.NotEqual(t, firstETag, updated.Header().Get("ETag"))
+
+	// Deuxième modification avec l'ancien ETag : la copie du client est périmée.
+	stale := do(http.MethodPatch, "/users/"+u.ID, `{"prenom": "Perceval"}`, firstETag)
+	assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+
+	staleDelete := do(http.MethodDelete, "/users/"+u.ID, "", firstETag)
+	assert.Equal(t, http.StatusPreconditionFailed, staleDelete.Code)
+
+	// Avec l'ETag courant, la suppression passe, même affaibli par un intermédiaire (W/).
+	deleted := do(http.MethodDelete, "/users/"+u.ID, "", "W/"+updated.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNoContent, deleted.Code)
 }
 
-// --- Implémentation de l'interface ---
+// =====================================================================================
+// PATCH (JSON Merge Patch, RFC 7396)
+// =====================================================================================
 
-func (f *fakeUserRepository) Create(ctx context.Context, u *user.User) error {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+func TestPatchUser_MergePatch(t *testing.T) {
+	const tenantA = "tenant-A"
+	adapter, repo := newTestRepository()
+	arthur := user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "arthur@kaamelott.com", Nom: "Pendragon", Prenom: "Arthur"}
+	seed(t, adapter, arthur)
+	seed(t, adapter, user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "guenievre@kaamelott.com", Nom: "De Carmelide"})
 
-	if u.TenantID == "" {
-		return fmt.Errorf("tenantID is required for creation")
+	handler := newTestHandler(user.NewService(repo))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedUser   *user.User
+	}{
+		{"Email normalisé", "application/merge-patch+json", `{"email": "  ROI@Kaamelott.com "}`, http.StatusOK,
+			&user.User{Email: "roi@kaamelott.com", Nom: "Pendragon", Prenom: "Arthur"}},
+		{"null efface le prénom", "application/merge-patch+json", `{"prenom": null}`, http.StatusOK,
+			&user.User{Email: "roi@kaamelott.com", Nom: "Pendragon", Prenom: ""}},
+		{"null refusé sur un champ obligatoire", "application/merge-patch+json", `{"nom": null}`, http.StatusBadRequest, nil},
+		{"Email déjà pris dans le tenant", "application/merge-patch+json", `{"email": "guenievre@kaamelott.com"}`, http.StatusConflict, nil},
+		{"Changement d'id refusé", "application/merge-patch+json", `{"id": "autre"}`, http.StatusBadRequest, nil},
+		{"Changement de tenant refusé", "application/merge-patch+json", `{"tenantID": "tenant-B"}`, http.StatusBadRequest, nil},
+		{"Champ inconnu refusé", "application/merge-patch+json", `{"role": "admin"}`, http.StatusBadRequest, nil},
+		{"Patch non objet", "application/merge-patch+json", `null`, http.StatusBadRequest, nil},
+		{"Content-Type non supporté", "text/plain", `{"nom": "Roi"}`, http.StatusUnsupportedMediaType, nil},
 	}
 
-	if u.ID == "" {
-		u.ID = uuid.NewString()
+	// Les cas s'enchaînent sur le même utilisateur : l'ordre compte.
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/users/"+arthur.ID, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), testPrincipal(tenantA)))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			if tc.expectedUser == nil {
+				return
+			}
+			var got user.User
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+			assert.Equal(t, arthur.ID, got.ID)
+			assert.Equal(t, tenantA, got.TenantID)
+			assert.Equal(t, tc.expectedUser.Email, got.Email)
+			assert.Equal(t, tc.expectedUser.Nom, got.Nom)
+			assert.Equal(t, tc.expectedUser.Prenom, got.Prenom)
+		})
 	}
+}
 
-	key := makeKey(u.TenantID, u.ID)
+// =====================================================================================
+// SUPPRESSION LOGIQUE / RESTAURATION
+// =====================================================================================
 
-	if _, exists := f.data[key]; exists {
-		return fmt.Errorf("user already exists with this ID in this tenant")
+func TestDeleteUser_SoftDeleteLifecycle(t *testing.T) {
+	const tenantA = "tenant-A"
+	adapter, repo := newTestRepository()
+	arthur := user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "arthur@kaamelott.com", Nom: "Pendragon"}
+	seed(t, adapter, arthur)
+
+	deletedAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	handler := newTestHandler(user.NewService(repo,
+		user.WithDeletedRetention(time.Hour),
+		user.WithClock(func() time.Time { return deletedAt }),
+	))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		ctx := auth.WithPrincipal(req.Context(), &auth.Principal{Subject: "admin-42", TenantID: tenantA, Permissions: []string{"*"}})
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req.WithContext(ctx))
+		return rr
 	}
 
-	f.data[key] = *u
+	// 1. Suppression : l'utilisateur disparaît des lectures par défaut.
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/users/"+arthur.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/users/"+arthur.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/users/"+arthur.ID, "").Code)
+	assert.NotContains(t, do(http.MethodGet, "/users", "").Body.String(), arthur.ID)
+
+	// 2. ... mais reste en base, avec l'auteur, la date et la rétention.
+	rr := do(http.MethodGet, "/users/"+arthur.ID+"?includeDeleted=true", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var deleted user.User
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&deleted))
+	require.NotNil(t, deleted.DeletedAt)
+	assert.True(t, deletedAt.Equal(*deleted.DeletedAt), "la date de suppression vient de l'horloge du service")
+	assert.Equal(t, "admin-42", deleted.DeletedBy)
+	assert.Equal(t, int(time.Hour.Seconds()), deleted.TTL)
+	assert.Contains(t, do(http.MethodGet, "/users?includeDeleted=true", "").Body.String(), arthur.ID)
+
+	// 3. L'email libéré est réattribué : la restauration est alors en conflit.
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/users", `{"email": "arthur@kaamelott.com", "nom": "Imposteur"}`).Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/users/"+arthur.ID+":restore", "").Code)
+
+	// 4. Une fois l'email de nouveau libre, la restauration rend l'utilisateur visible.
+	page, err := adapter.Search(context.Background(), database.NewQuery[user.User]().Where(database.Eq("nom", "Imposteur")), tenantA)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	require.NoError(t, adapter.Delete(context.Background(), page.Items[0].ID, tenantA))
+
+	rr = do(http.MethodPost, "/users/"+arthur.ID+":restore", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var restored user.User
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&restored))
+	assert.Nil(t, restored.DeletedAt)
+	assert.Empty(t, restored.DeletedBy)
+	assert.Zero(t, restored.TTL)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users/"+arthur.ID, "").Code)
+}
+
+func TestIncludeDeleted_RequiresAdmin(t *testing.T) {
+	const tenantA = "tenant-A"
+	adapter, repo := newTestRepository()
+	arthur := user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "arthur@kaamelott.com", Nom: "Pendragon"}
+	seed(t, adapter, arthur)
 
-	return nil
+	handler := newTestHandler(user.NewService(repo))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	policy := auth.NewPolicy(auth.DefaultRoles)
+	tests := []struct {
+		name           string
+		role           string
+		target         string
+		expectedStatus int
+	}{
+		{"Membre : recherche sans les supprimés", auth.RoleMember, "/users", http.StatusOK},
+		{"Membre : recherche avec les supprimés refusée", auth.RoleMember, "/users?includeDeleted=true", http.StatusForbidden},
+		{"Membre : lecture avec les supprimés refusée", auth.RoleMember, "/users/" + arthur.ID + "?includeDeleted=true", http.StatusForbidden},
+		{"Manager : recherche avec les supprimés refusée", auth.RoleManager, "/users?includeDeleted=true", http.StatusForbidden},
+		{"Administrateur : recherche avec les supprimés", auth.RoleTenantAdmin, "/users?includeDeleted=true", http.StatusOK},
+		{"Administrateur : lecture avec les supprimés", auth.RoleTenantAdmin, "/users/" + arthur.ID + "?includeDeleted=true", http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			principal := &auth.Principal{Subject: "u-1", TenantID: tenantA, Roles: []string{tc.role}}
+			principal.Permissions = policy.Permissions(tenantA, principal.Roles)
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+		})
+	}
 }
 
-func (f *fakeUserRepository) GetByID(ctx context.Context, tenantID string, id string) (*user.User, error) {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
+// =====================================================================================
+// ATTRIBUTION DES RÔLES
+// =====================================================================================
+
+func TestUserRoles_AdminOnly(t *testing.T) {
+	const tenantA = "tenant-A"
+	adapter, repo := newTestRepository()
+	arthur := user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "arthur@kaamelott.com", Nom: "Pendragon", Roles: []string{auth.RoleMember}}
+	seed(t, adapter, arthur)
 
-	key := makeKey(tenantID, id)
-	val, ok := f.data[key]
-	if !ok {
-		return nil, fmt.Errorf("user not found")
+	handler := newTestHandler(user.NewService(repo, user.WithNotifier(&recordingNotifier{})))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	policy := auth.NewPolicy(auth.DefaultRoles)
+	do := func(role, method, target, body string) *httptest.ResponseRecorder {
+		principal := &auth.Principal{Subject: "u-1", TenantID: tenantA, Roles: []string{role}}
+		principal.Permissions = policy.Permissions(tenantA, principal.Roles)
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if method == http.MethodPatch {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+		return rr
+	}
+	roles := func(rr *httptest.ResponseRecorder) []string {
+		var u user.User
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&u))
+		return u.Roles
 	}
 
-	copyVal := val
-	return &copyVal, nil
+	// Sans rôles demandés, l'invité est membre ; un manager peut inviter.
+	rr := do(auth.RoleManager, http.MethodPost, "/users/invitations", `{"email": "bohort@kaamelott.com", "nom": "De Gaunes"}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, []string{auth.RoleMember}, roles(rr))
+
+	// Attribuer des rôles est réservé aux administrateurs, à l'invitation comme en modification.
+	rr = do(auth.RoleManager, http.MethodPost, "/users/invitations", `{"email": "lancelot@kaamelott.com", "nom": "Du Lac", "roles": ["tenant-admin"]}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	rr = do(auth.RoleManager, http.MethodPatch, "/users/"+arthur.ID, `{"roles": ["tenant-admin"]}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = do(auth.RoleTenantAdmin, http.MethodPost, "/users/invitations", `{"email": "lancelot@kaamelott.com", "nom": "Du Lac", "roles": [" manager ", "manager"]}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, []string{auth.RoleManager}, roles(rr))
+
+	rr = do(auth.RoleTenantAdmin, http.MethodPatch, "/users/"+arthur.ID, `{"roles": ["tenant-admin", "manager"]}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, []string{auth.RoleTenantAdmin, auth.RoleManager}, roles(rr))
+
+	// Un compte garde au moins un rôle.
+	assert.Equal(t, http.StatusBadRequest, do(auth.RoleTenantAdmin, http.MethodPatch, "/users/"+arthur.ID, `{"roles": []}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(auth.RoleTenantAdmin, http.MethodPatch, "/users/"+arthur.ID, `{"roles": null}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(auth.RoleTenantAdmin, http.MethodPatch, "/users/"+arthur.ID, `{"roles": "manager"}`).Code)
 }
 
-func (f *fakeUserRepository) Update(ctx context.Context, u *user.User) error {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+func TestUserAdminAccounts_Protected(t *testing.T) {
+	const tenantA = "tenant-A"
+	adapter, repo := newTestRepository()
+	arthur := user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "arthur@kaamelott.com", Nom: "Pendragon", Status: user.StatusActive, Roles: []string{auth.RoleTenantAdmin}}
+	perceval := user.User{ID: uuid.NewString(), TenantID: tenantA, Email: "perceval@kaamelott.com", Nom: "De Galles", Status: user.StatusActive, Roles: []string{auth.RoleMember}}
+	seed(t, adapter, arthur)
+	seed(t, adapter, perceval)
 
-	if u.TenantID == "" || u.ID == "" {
-		return fmt.Errorf("missing tenantID or ID for update")
+	handler := newTestHandler(user.NewService(repo))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	policy := auth.NewPolicy(auth.DefaultRoles)
+	do := func(role, method, target, body string) *httptest.ResponseRecorder {
+		principal := &auth.Principal{Subject: "u-1", TenantID: tenantA, Roles: []string{role}}
+		principal.Permissions = policy.Permissions(tenantA, principal.Roles)
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if method == http.MethodPatch {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+		return rr
 	}
 
-	key := makeKey(u.TenantID, u.ID)
+	// Un manager ne désactive personne et ne supprime pas un administrateur.
+	assert.Equal(t, http.StatusForbidden, do(auth.RoleManager, http.MethodPatch, "/users/"+arthur.ID, `{"status": "disabled"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(auth.RoleManager, http.MethodPatch, "/users/"+perceval.ID, `{"status": "disabled"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(auth.RoleManager, http.MethodDelete, "/users/"+arthur.ID, "").Code)
+
+	// Il reste libre de modifier les autres champs et de supprimer un simple membre.
+	rr := do(auth.RoleManager, http.MethodPatch, "/users/"+arthur.ID, `{"prenom": "Arthur"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, http.StatusNoContent, do(auth.RoleManager, http.MethodDelete, "/users/"+perceval.ID, "").Code)
+
+	// L'administrateur garde la main.
+	rr = do(auth.RoleTenantAdmin, http.MethodPatch, "/users/"+arthur.ID, `{"status": "disabled"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, http.StatusNoContent, do(auth.RoleTenantAdmin, http.MethodDelete, "/users/"+arthur.ID, "").Code)
+}
+
+// =====================================================================================
+// PAGINATION PAR CURSEUR
+// =====================================================================================
 
-	if _, exists := f.data[key]; !exists {
-		return fmt.Errorf("user not found for update")
+func TestSearchUsers_Cursor(t *testing.T) {
+	adapter, repo := newTestRepository()
+	for i := 0; i < 5; i++ {
+		seed(t, adapter, user.User{ID: uuid.NewString(), TenantID: "tenant-A", Email: fmt.Sprintf("chevalier%d@kaamelott.com", i), Nom: "Chevalier", Status: user.StatusActive})
+	}
+	handler := newTestHandler(user.NewService(repo))
+	r := chi.NewRouter()
+	r.Route("/users", handler.RegisterRoutes)
+
+	search := func(tenantID, target string) (*httptest.ResponseRecorder, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), testPrincipal(tenantID)))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		var body map[string]any
+		_ = json.Unmarshal(rr.Body.Bytes(), &body)
+		return rr, body
 	}
 
-	f.data[key] = *u
-	return nil
+	// On parcourt toutes les pages en suivant nextCursor.
+	seen := map[string]bool{}
+	target := "/users?nom=Chevalier&limit=2"
+	var firstCursor string
+	for pages := 0; target != ""; pages++ {
+		require.Less(t, pages, 5, "la pagination doit se terminer")
+
+		rr, body := search("tenant-A", target)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		for _, item := range body["items"].([]any) {
+			seen[item.(map[string]any)["id"].(string)] = true
+		}
+
+		target = ""
+		if next, ok := body["nextCursor"].(string); ok {
+			if firstCursor == "" {
+				firstCursor = next
+			}
+			assert.Contains(t, rr.Header().Get("Link"), `rel="next"`)
+			target = "/users?nom=Chevalier&limit=2&cursor=" + next
+		}
+	}
+	assert.Len(t, seen, 5)
+	require.NotEmpty(t, firstCursor)
+
+	// Un curseur ne peut pas être rejoué sur un autre tenant, ni avec d'autres critères, ni altéré.
+	rr, _ := search("tenant-B", "/users?nom=Chevalier&limit=2&cursor="+firstCursor)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr, _ = search("tenant-A", "/users?nom=Autre&limit=2&cursor="+firstCursor)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr, _ = search("tenant-A", "/users?nom=Chevalier&limit=2&cursor=x"+firstCursor)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Les valeurs des critères sont échappées : un nom contenant "&status=" n'imite pas un autre filtre.
+	rr, body := search("tenant-A", "/users?nom=Chevalier&status=active&limit=2")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	statusCursor, _ := body["nextCursor"].(string)
+	require.NotEmpty(t, statusCursor)
+	rr, _ = search("tenant-A", "/users?nom=Chevalier%26status%3Dactive&limit=2&cursor="+statusCursor)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
 
-func (f *fakeUserRepository) Delete(ctx context.Context, tenantID string, id string) error {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+func TestInvitation_Flow(t *testing.T) {
+	const tenantA = "tenant-A"
+	const password = "Table-Ronde-Kaamelott"
+	_, repo := newTestRepository()
+	notifier := &recordingNotifier{}
+	svc := user.NewService(repo, user.WithNotifier(notifier), user.WithInvitationBaseURL("https://erp.test/invitations/"))
+	handler := newTestHandler(svc)
 
-	key := makeKey(tenantID, id)
-	if _, exists := f.data[key]; !exists {
-		return fmt.Errorf("user not found for delete")
+	r := chi.NewRouter()
+	r.Route("/users", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req.WithContext(auth.WithPrincipal(req.Context(), testPrincipal(tenantA))))
+			})
+		})
+		handler.RegisterRoutes(r)
+	})
+	r.Route("/auth", handler.RegisterPublicRoutes)
+
+	do := func(method, target string, body any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, target, bytes.NewReader(data)))
+		return rr
 	}
 
-	delete(f.data, key)
-	return nil
+	// 1. L'invitation crée un compte en attente et envoie le lien.
+	rr := do(http.MethodPost, "/users/invitations", user.InviteUserInput{Email: "Perceval@Kaamelott.com", Nom: "De Galles", Prenom: "Perceval"})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var invited user.User
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&invited))
+	assert.Equal(t, user.StatusInvited, invited.Status)
+	assert.Empty(t, invited.InvitationNonceHash)
+
+	require.Len(t, notifier.sent, 1)
+	sent := notifier.sent[0]
+	assert.Equal(t, "perceval@kaamelott.com", sent.Email)
+	require.True(t, strings.HasPrefix(sent.Link, "https://erp.test/invitations/"))
+	token := strings.TrimPrefix(sent.Link, "https://erp.test/invitations/")
+
+	// Email déjà pris, y compris par un invité.
+	rr = do(http.MethodPost, "/users/invitations", user.InviteUserInput{Email: "perceval@kaamelott.com", Nom: "De Galles"})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// 2. Un invité ne peut pas se connecter, et n'est pas activable par PATCH.
+	_, err := svc.VerifyCredentials(context.Background(), tenantA, "perceval@kaamelott.com", password)
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	active := user.StatusActive
+	_, err = svc.UpdateUser(context.Background(), tenantA, invited.ID, user.UpdateUserInput{Status: &active}, "")
+	assert.ErrorIs(t, err, user.ErrInvalidStatusTransition)
+
+	// 3. La recherche filtre par état.
+	rr = do(http.MethodGet, "/users?status=invited", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), invited.ID)
+	rr = do(http.MethodGet, "/users?status=active", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), invited.ID)
+
+	// 4. Jeton altéré, mot de passe refusé par la politique.
+	tampered := token[:len(token)-2] + "xx"
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/auth/invitations/"+tampered+"/accept", user.AcceptInvitationInput{Password: password}).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/auth/invitations/"+token+"/accept", user.AcceptInvitationInput{Password: "court"}).Code)
+
+	// 5. L'acceptation active le compte : la connexion fonctionne.
+	rr = do(http.MethodPost, "/auth/invitations/"+token+"/accept", user.AcceptInvitationInput{Password: password})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var activated user.User
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&activated))
+	assert.Equal(t, user.StatusActive, activated.Status)
+	assert.Empty(t, activated.PasswordHash)
+	assert.Nil(t, activated.InvitationExpiresAt)
+
+	u, err := svc.VerifyCredentials(context.Background(), tenantA, "perceval@kaamelott.com", password)
+	require.NoError(t, err)
+	assert.Equal(t, invited.ID, u.ID)
+
+	// 6. Usage unique.
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/auth/invitations/"+token+"/accept", user.AcceptInvitationInput{Password: "Autre-Mot-De-Passe-2024"}).Code)
+
+	// 7. Un compte désactivé ne peut plus se connecter.
+	disabled := user.StatusDisabled
+	_, err = svc.UpdateUser(context.Background(), tenantA, invited.ID, user.UpdateUserInput{Status: &disabled}, "")
+	require.NoError(t, err)
+	_, err = svc.VerifyCredentials(context.Background(), tenantA, "perceval@kaamelott.com", password)
+	assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+
+	// 8. Envoi en échec : le compte n'est pas conservé, l'invitation peut être relancée.
+	notifier.err = errors.New("smtp: connection refused")
+	rr = do(http.MethodPost, "/users/invitations", user.InviteUserInput{Email: "lancelot@kaamelott.com", Nom: "Du Lac"})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	rr = do(http.MethodGet, "/users?email=lancelot@kaamelott.com", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "lancelot")
+
+	notifier.err = nil
+	rr = do(http.MethodPost, "/users/invitations", user.InviteUserInput{Email: "lancelot@kaamelott.com", Nom: "Du Lac"})
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
 }
 
-// Search implémente une recherche basique en mémoire
-func (f *fakeUserRepository) Search(ctx context.Context, tenantID string, filter user.Filter) ([]user.User, error) {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
+func TestInvitation_Expired(t *testing.T) {
+	_, repo := newTestRepository()
+	notifier := &recordingNotifier{}
+	svc := user.NewService(repo, user.WithNotifier(notifier), user.WithInvitationBaseURL(""), user.WithInvitationTTL(time.Nanosecond))
 
-	var results []user.User
+	_, err := svc.InviteUser(context.Background(), "tenant-A", user.InviteUserInput{Email: "karadoc@kaamelott.com", Nom: "De Vannes"})
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 1)
 
-	// On itère sur toute la map (pas optimal en prod, ok pour un fake)
-	for _, v := range f.data {
-		// 1. Filtrage obligatoire sur le TenantID
-		if v.TenantID != tenantID {
-			continue
-		}
+	_, err = svc.AcceptInvitation(context.Background(), notifier.sent[0].Link, user.AcceptInvitationInput{Password: "Le-Gras-Cest-La-Vie"})
+	assert.ErrorIs(t, err, user.ErrInvalidInvitation)
+}
 
-		// 2. Application des autres filtres de la struct 'Filter'
-		// EXEMPLE (à adapter selon votre struct Filter réelle) :
-		// if filter.Email != "" && v.Email != filter.Email { continue }
+// recordingNotifier conserve les invitations envoyées ; err simule une panne d'envoi.
+type recordingNotifier struct {
+	sent []user.Invitation
+	err  error
+}
 
-		// Si un filtre est trop complexe pour le fake on peut l'ingorer ou panique
-		//panic("Fake repo does not support complex date range filtering. Use integration test instead.")
-		results = append(results, v)
+func (n *recordingNotifier) SendInvitation(_ context.Context, inv user.Invitation) error {
+	if n.err != nil {
+		return n.err
 	}
+	n.sent = append(n.sent, inv)
+	return nil
+}
+
+// =====================================================================================
+// REPOSITORY DE TEST
+// =====================================================================================
+
+// testPrincipal simule un administrateur authentifié du tenant.
+func testPrincipal(tenantID string) *auth.Principal {
+	return &auth.Principal{Subject: "test-user", TenantID: tenantID, Roles: []string{auth.RoleTenantAdmin}, Permissions: []string{"*"}}
+}
+
+// newTestHandler crée le handler avec une clé de signature des curseurs propre au test.
+func newTestHandler(s user.Service) *user.Handler {
+	return user.NewHandler(s, pagination.NewSigner([]byte("test-cursor-key")))
+}
 
-	return results, nil
+// newTestRepository branche le vrai repository User sur l'adaptateur en mémoire du kit :
+// mêmes filtres, ETags et erreurs que Cosmos, sans fake à maintenir.
+func newTestRepository() (*memory.Adapter[user.User], user.Repository) {
+	adapter := memory.NewAdapter[user.User]()
+	return adapter, user.NewCosmosRepository(adapter)
+}
+
+// seed insère directement un utilisateur dans la base de test.
+func seed(t *testing.T, adapter *memory.Adapter[user.User], u user.User) {
+	t.Helper()
+	_, err := adapter.Create(context.Background(), u)
+	require.NoError(t, err)
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"test-api/kit/apperr"
+)
+
+// errorMapping associe une erreur cible à un statut HTTP, un type de problème et un message public par défaut.
+type errorMapping struct {
+	target      error
+	status      int
+	problemType string
+	message     string
+}
+
+var (
+	registryMu sync.RWMutex
+
+	// errorRegistry est parcouru dans l'ordre : la première correspondance (errors.Is) l'emporte.
+	// Les enregistrements faits via RegisterError sont placés devant les catégories par défaut.
+	errorRegistry = []errorMapping{
+		{apperr.ErrInvalidInput, http.StatusBadRequest, "invalid-input", "Les données fournies sont invalides."},
+		{apperr.ErrUnauthorized, http.StatusUnauthorized, "unauthorized", "Accès non autorisé. Veuillez vous authentifier."},
+		{apperr.ErrForbidden, http.StatusForbidden, "forbidden", "Vous n'avez pas les droits pour effectuer cette action."},
+		{apperr.ErrNotFound, http.StatusNotFound, "not-found", "La ressource demandée n'existe pas."},
+		{apperr.ErrConflict, http.StatusConflict, "conflict", "La ressource existe déjà ou est en conflit."},
+		{apperr.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition-failed", "La ressource a été modifiée entre-temps."},
+		{apperr.ErrRateLimited, http.StatusTooManyRequests, "rate-limited", "Trop de requêtes, veuillez réessayer plus tard."},
+		{apperr.ErrInternal, http.StatusInternalServerError, "internal", internalErrorMessage},
+	}
+
+	internalErrorMapping = errorMapping{apperr.ErrInternal, http.StatusInternalServerError, "internal", internalErrorMessage}
+)
+
+const internalErrorMessage = "Internal Server Error"
+
+// RegisterError ajoute (ou surcharge) le mapping d'une erreur vers un statut HTTP.
+// Utile pour une erreur qui n'enveloppe aucune catégorie de kit/apperr (ex: erreur d'une lib tierce).
+// À appeler au démarrage, avant de servir des requêtes.
+func RegisterError(target error, status int, message string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	errorRegistry = append([]errorMapping{{target: target, status: status, message: message}}, errorRegistry...)
+}
+
+// resolveError détermine le statut HTTP, le type de problème et le message public pour une erreur.
+// Les erreurs non reconnues donnent une 500 avec un message générique : on ne fuite jamais err.Error().
+func resolveError(err error) errorMapping {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, m := range errorRegistry {
+		if !errors.Is(err, m.target) {
+			continue
+		}
+
+		// Pour une 5xx, on ne renvoie jamais le détail, même s'il se dit "public".
+		if m.status >= http.StatusInternalServerError {
+			return m
+		}
+
+		// Plusieurs erreurs de champ : le détail reste générique, la liste est dans Problem.Errors.
+		if len(apperr.FieldErrors(err)) > 1 {
+			return m
+		}
+
+		// Si l'erreur porte son propre message public (ex: "user not found"), il est prioritaire.
+		var pub apperr.PublicError
+		if errors.As(err, &pub) && pub.PublicMessage() != "" {
+			m.message = pub.PublicMessage()
+		}
+		return m
+	}
+
+	return internalErrorMapping
+}
@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+)
+
+// respond passe err par RespondWithError et décode le document problem+json obtenu.
+func respond(t *testing.T, err error) (*httptest.ResponseRecorder, api.Problem) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	api.RespondWithError(rec, httptest.NewRequest(http.MethodGet, "/", nil), err)
+
+	var p api.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	return rec, p
+}
+
+func TestRespondWithError_Mapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+	}{
+		{"Données invalides", apperr.New(apperr.ErrInvalidInput, "email invalide"), http.StatusBadRequest, "/problems/invalid-input"},
+		{"Non authentifié", apperr.ErrUnauthorized, http.StatusUnauthorized, "/problems/unauthorized"},
+		{"Interdit", apperr.ErrForbidden, http.StatusForbidden, "/problems/forbidden"},
+		{"Introuvable", apperr.New(apperr.ErrNotFound, "user not found"), http.StatusNotFound, "/problems/not-found"},
+		{"Conflit", apperr.ErrConflict, http.StatusConflict, "/problems/conflict"},
+		{"Précondition", apperr.ErrPreconditionFailed, http.StatusPreconditionFailed, "/problems/precondition-failed"},
+		{"Trop de requêtes", apperr.ErrRateLimited, http.StatusTooManyRequests, "/problems/rate-limited"},
+		{"Interne", apperr.ErrInternal, http.StatusInternalServerError, "/problems/internal"},
+		{"Catégorie enveloppée", fmt.Errorf("repository: %w", apperr.ErrNotFound), http.StatusNotFound, "/problems/not-found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, p := respond(t, tc.err)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.status, p.Status)
+			assert.Equal(t, tc.typ, p.Type)
+			assert.Equal(t, http.StatusText(tc.status), p.Title)
+		})
+	}
+}
+
+func TestRespondWithError_PublicMessage(t *testing.T) {
+	_, p := respond(t, apperr.New(apperr.ErrNotFound, "user not found"))
+	assert.Equal(t, "user not found", p.Detail, "le message public de l'erreur remplace le message par défaut")
+}
+
+func TestRegisterError_OverridesDefault(t *testing.T) {
+	// L'erreur enveloppe aussi ErrNotFound : sans l'enregistrement, elle donnerait une 404.
+	errGone := errors.New("resource gone")
+	api.RegisterError(errGone, http.StatusGone, "La ressource a été supprimée définitivement.")
+
+	rec, p := respond(t, fmt.Errorf("%w: %w", errGone, apperr.ErrNotFound))
+
+	assert.Equal(t, http.StatusGone, rec.Code)
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, "La ressource a été supprimée définitivement.", p.Detail)
+}
+
+func TestRespondWithError_UnknownError(t *testing.T) {
+	rec, p := respond(t, errors.New("dial tcp 10.0.0.12:443: connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "/problems/internal", p.Type)
+	assert.NotContains(t, rec.Body.String(), "10.0.0.12", "le message technique ne doit jamais sortir")
+	assert.NotContains(t, rec.Body.String(), "connection refused")
+}
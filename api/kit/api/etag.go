@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetETag expose la version d'une ressource dans l'en-tête HTTP ETag.
+// Les ETags Cosmos sont déjà entre guillemets ; on ajoute les guillemets sinon (RFC 9110).
+func SetETag(w http.ResponseWriter, etag string) {
+	if etag == "" {
+		return
+	}
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	w.Header().Set("ETag", etag)
+}
+
+// IfMatch retourne l'ETag attendu par le client (en-tête If-Match).
+// Vide si l'en-tête est absent ou vaut "*" (n'importe quelle version).
+// Le préfixe "W/" est retiré : un proxy qui compresse la réponse affaiblit l'ETag, mais la
+// version désignée reste la même (nos ETags sont tous forts).
+func IfMatch(r *http.Request) string {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "*" {
+		return ""
+	}
+	return strings.TrimPrefix(v, "W/")
+}
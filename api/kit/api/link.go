@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+)
+
+// CursorParam est le nom du paramètre d'URL portant le curseur de pagination.
+const CursorParam = "cursor"
+
+// SetNextLink ajoute l'en-tête Link rel="next" (RFC 8288) : même URL que la requête courante,
+// avec le curseur de la page suivante. Ne fait rien si cursor est vide (dernière page).
+func SetNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	if cursor == "" {
+		return
+	}
+
+	next := *r.URL
+	q := next.Query()
+	q.Set(CursorParam, cursor)
+	next.RawQuery = q.Encode()
+	next.Scheme, next.Host = "", ""
+
+	w.Header().Add("Link", "<"+next.RequestURI()+`>; rel="next"`)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"test-api/kit/apperr"
+)
+
+// MergePatchContentType est le media type JSON Merge Patch (RFC 7396).
+const MergePatchContentType = "application/merge-patch+json"
+
+// ErrUnsupportedMediaType est renvoyée quand le Content-Type de la requête n'est pas accepté (415).
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+func init() {
+	RegisterError(ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "Content-Type non supporté.")
+}
+
+// DecodeMergePatch lit un document JSON Merge Patch (RFC 7396).
+// Chaque membre présent est une modification ; une valeur JSON null (json.RawMessage "null")
+// signifie "supprimer / remettre à zéro". application/json est accepté par tolérance.
+func DecodeMergePatch(r *http.Request) (map[string]json.RawMessage, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+		return nil, fmt.Errorf("expected %s, got %q: %w", MergePatchContentType, r.Header.Get("Content-Type"), ErrUnsupportedMediaType)
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		return nil, apperr.Wrap(apperr.ErrInvalidInput, "invalid JSON merge patch", err)
+	}
+	if patch == nil {
+		// Un patch qui n'est pas un objet (ex: null) remplacerait la ressource entière : refusé.
+		return nil, apperr.New(apperr.ErrInvalidInput, "JSON merge patch must be an object")
+	}
+	return patch, nil
+}
+
+// IsNull indique si un membre de merge patch vaut null.
+func IsNull(v json.RawMessage) bool {
+	return string(v) == "null"
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"test-api/kit/apperr"
+	"test-api/kit/logger"
+)
+
+// ProblemContentType est le media type des réponses d'erreur (RFC 9457).
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBaseURI préfixe l'identifiant de type des problèmes ("/problems/not-found"...).
+// Il peut être surchargé au démarrage pour pointer vers une vraie page de documentation.
+var ProblemTypeBaseURI = "/problems/"
+
+// Problem est le document JSON renvoyé au client pour toute erreur (RFC 9457).
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Errors liste les erreurs de validation champ par champ (uniquement pour les 400).
+	Errors []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// newProblem construit le document RFC 9457 correspondant à err.
+func newProblem(r *http.Request, err error) Problem {
+	m := resolveError(err)
+
+	p := Problem{
+		Type:   problemType(m.problemType),
+		Title:  http.StatusText(m.status),
+		Status: m.status,
+	}
+	// Une 5xx n'a pas de détail : le titre suffit et rien d'interne ne doit sortir.
+	if m.status < http.StatusInternalServerError {
+		p.Detail = m.message
+	}
+	if r != nil {
+		// L'ID posé par middleware.RequestID permet de retrouver la ligne de log côté serveur.
+		p.Instance = middleware.GetReqID(r.Context())
+	}
+	if m.status == http.StatusBadRequest {
+		p.Errors = apperr.FieldErrors(err)
+	}
+	return p
+}
+
+func problemType(slug string) string {
+	if slug == "" {
+		return "about:blank"
+	}
+	return ProblemTypeBaseURI + slug
+}
+
+// respondWithProblem écrit le document problem+json.
+func respondWithProblem(ctx context.Context, w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		logger.Error(ctx, "Échec de l'encodage de la réponse problem+json", "error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+)
+
+// serveProblem renvoie err derrière middleware.RequestID, comme le fait le routeur de l'application.
+// Il retourne la réponse brute et l'ID de requête vu par le handler.
+func serveProblem(t *testing.T, err error) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	var reqID string
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		reqID = middleware.GetReqID(r.Context())
+		api.RespondWithError(w, r, err)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NotEmpty(t, reqID)
+	return rec, reqID
+}
+
+func TestProblem_Document(t *testing.T) {
+	err := errors.Join(
+		apperr.InvalidField("email", "format invalide"),
+		apperr.InvalidField("nom", "obligatoire"),
+	)
+
+	rec, reqID := serveProblem(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, api.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	var p api.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, "/problems/invalid-input", p.Type)
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+	assert.Equal(t, reqID, p.Instance, "instance reprend l'ID de requête pour retrouver le log")
+	assert.Equal(t, []apperr.FieldError{
+		{Field: "email", Message: "format invalide"},
+		{Field: "nom", Message: "obligatoire"},
+	}, p.Errors)
+	assert.Equal(t, "Les données fournies sont invalides.", p.Detail, "plusieurs champs : détail générique")
+}
+
+func TestProblem_ServerErrorHasNoDetail(t *testing.T) {
+	rec, reqID := serveProblem(t, apperr.Wrap(apperr.ErrInternal, "cosmos indisponible", errors.New("timeout")))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, api.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.NotContains(t, doc, "detail")
+	assert.NotContains(t, doc, "errors")
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), doc["title"])
+	assert.Equal(t, reqID, doc["instance"])
+}
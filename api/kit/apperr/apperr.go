@@ -0,0 +1,135 @@
+// Package apperr définit la taxonomie des erreurs applicatives partagée par tous les modules.
+//
+// Les domaines (internal/...) ne renvoient jamais de code HTTP : ils enveloppent une des
+// sentinelles ci-dessous, et c'est kit/api qui traduit la catégorie en statut HTTP.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinelles de catégorie. On les teste toujours avec errors.Is.
+var (
+	ErrNotFound           = errors.New("resource not found")
+	ErrConflict           = errors.New("resource conflict")
+	ErrInvalidInput       = errors.New("invalid input")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrInternal           = errors.New("internal error")
+)
+
+// PublicError est implémentée par les erreurs dont le message peut être renvoyé tel quel au client.
+type PublicError interface {
+	error
+	PublicMessage() string
+}
+
+// Error associe une catégorie (Kind), un message public et une éventuelle cause technique.
+type Error struct {
+	// Kind est une des sentinelles du package (ErrNotFound, ErrConflict...).
+	Kind error
+	// Message est le texte destiné au client, il ne doit contenir aucun détail d'infrastructure.
+	Message string
+	// Err est la cause technique, uniquement destinée aux logs.
+	Err error
+}
+
+// New crée une erreur de catégorie kind avec un message public.
+// Utilisé pour déclarer les erreurs métier : var ErrUserNotFound = apperr.New(apperr.ErrNotFound, "user not found")
+func New(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap enveloppe une cause technique dans une erreur de catégorie kind.
+func Wrap(kind error, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// PublicMessage retourne le message destiné au client.
+func (e *Error) PublicMessage() string {
+	return e.Message
+}
+
+// Unwrap expose à la fois la catégorie et la cause, pour que errors.Is fonctionne sur les deux.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// =================================================================================
+// Erreurs de validation par champ
+// =================================================================================
+
+// FieldError décrit une erreur de validation sur un champ précis de l'entrée.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrorer est implémentée par les erreurs qui portent un ou plusieurs FieldError.
+type FieldErrorer interface {
+	FieldErrors() []FieldError
+}
+
+// FieldErrors collecte tous les FieldError présents dans la chaîne d'erreurs,
+// y compris à travers errors.Join (contrairement à errors.As qui s'arrête au premier).
+func FieldErrors(err error) []FieldError {
+	if err == nil {
+		return nil
+	}
+
+	if fe, ok := err.(FieldErrorer); ok {
+		return fe.FieldErrors()
+	}
+
+	switch x := err.(type) {
+	case interface{ Unwrap() []error }:
+		var out []FieldError
+		for _, e := range x.Unwrap() {
+			out = append(out, FieldErrors(e)...)
+		}
+		return out
+	case interface{ Unwrap() error }:
+		return FieldErrors(x.Unwrap())
+	}
+	return nil
+}
+
+// InvalidField construit une erreur de validation (400) portant un FieldError.
+// Plusieurs erreurs peuvent être combinées avec errors.Join.
+func InvalidField(field, message string) error {
+	return &fieldError{FieldError{Field: field, Message: message}}
+}
+
+type fieldError struct {
+	FieldError
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("invalid input for field '%s': %s", e.Field, e.Message)
+}
+
+// PublicMessage : le détail de validation est destiné au client.
+func (e *fieldError) PublicMessage() string {
+	return e.Error()
+}
+
+func (e *fieldError) FieldErrors() []FieldError {
+	return []FieldError{e.FieldError}
+}
+
+func (e *fieldError) Unwrap() error {
+	return ErrInvalidInput
+}
@@ -1,62 +1,250 @@
-package main
+package auth
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+	"test-api/kit/logger"
 )
 
-// TODO à tester et valider
+// -- Erreurs --
+// Le message reste volontairement vague pour le client : le détail part dans les logs.
 
-// Votre clé secrète (doit être la MÊME que celle utilisée pour créer le token lors du login)
-// À stocker impérativement dans les Application Settings de la Function App (ex: JWT_SECRET)
-var jwtSecretKey = []byte(os.Getenv("JWT_SECRET"))
+var ErrMissingToken = apperr.New(apperr.ErrUnauthorized, "missing bearer token or API key")
+var ErrInvalidToken = apperr.New(apperr.ErrUnauthorized, "invalid or expired token")
 
-// AuthMiddleware est une fonction qui prend votre handler final et retourne un nouveau handler sécurisé
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// 1. Extraire le header Authorization
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
-			return
+// Claims sont les claims attendus dans les jetons d'accès.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// TenantID suit la convention Entra ID ("tid") ; "tenant" est accepté pour les jetons émis par nos soins.
+	TenantID string   `json:"tid,omitempty"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+}
+
+// Principal convertit les claims validés en identité applicative.
+func (c *Claims) Principal() (*Principal, error) {
+	tenantID := c.TenantID
+	if tenantID == "" {
+		tenantID = c.Tenant
+	}
+	if c.Subject == "" {
+		return nil, errors.New("missing sub claim")
+	}
+	if tenantID == "" {
+		return nil, errors.New("missing tid/tenant claim")
+	}
+	return &Principal{
+		Subject:  c.Subject,
+		TenantID: tenantID,
+		Roles:    c.Roles,
+	}, nil
+}
+
+// =================================================================================
+// Authenticator
+// =================================================================================
+
+// DefaultLeeway est la tolérance de décalage d'horloge appliquée à exp/nbf/iat.
+const DefaultLeeway = time.Minute
+
+// Authenticator valide les jetons bearer et en extrait le Principal.
+type Authenticator struct {
+	keyFunc jwt.Keyfunc
+	parser  *jwt.Parser
+	issuers []string
+	policy  *Policy
+	apiKeys APIKeyVerifier
+}
+
+// Option configure la validation des jetons.
+type Option func(*validation)
+
+type validation struct {
+	issuers   []string
+	audiences []string
+	leeway    time.Duration
+	policy    *Policy
+	apiKeys   APIKeyVerifier
+}
+
+// APIKeyVerifier authentifie une clé d'API (intégrations machine à machine).
+// Le Principal retourné porte directement ses permissions (les scopes de la clé).
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// WithIssuers restreint les émetteurs ("iss") acceptés. Le motif "{tenantid}" est remplacé par
+// le tenant du jeton : https://login.microsoftonline.com/{tenantid}/v2.0 accepte ainsi
+// la connexion multi-tenant Entra ID sans lister chaque client.
+func WithIssuers(issuers ...string) Option {
+	return func(v *validation) { v.issuers = append(v.issuers, issuers...) }
+}
+
+// WithAudience restreint les audiences ("aud") acceptées : le jeton doit en viser au moins une.
+func WithAudience(audiences ...string) Option {
+	return func(v *validation) { v.audiences = append(v.audiences, audiences...) }
+}
+
+// WithLeeway définit la tolérance de décalage d'horloge (DefaultLeeway par défaut).
+func WithLeeway(d time.Duration) Option {
+	return func(v *validation) { v.leeway = d }
+}
+
+// WithPolicy définit la résolution rôles/permissions (NewPolicy(DefaultRoles) par défaut).
+func WithPolicy(p *Policy) Option {
+	return func(v *validation) { v.policy = p }
+}
+
+// WithAPIKeys accepte aussi les clés d'API ("X-API-Key: <clé>" ou "Authorization: ApiKey <clé>").
+func WithAPIKeys(v APIKeyVerifier) Option {
+	return func(o *validation) { o.apiKeys = v }
+}
+
+// NewHMACAuthenticator valide des jetons HS256/HS384/HS512 signés avec un secret partagé
+// (le même que celui utilisé pour les émettre, ex : JWT_SECRET). L'émetteur est obligatoire :
+// il s'agit au moins de celui des jetons émis localement (voir TokenIssuer), les autres sont refusés.
+func NewHMACAuthenticator(secret []byte, opts ...Option) (*Authenticator, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("auth: empty HMAC secret")
+	}
+	keyFunc := func(*jwt.Token) (any, error) { return secret, nil }
+	a, v := newAuthenticator(keyFunc, []string{"HS256", "HS384", "HS512"}, opts)
+	if len(v.issuers) == 0 {
+		return nil, errors.New("auth: HMAC validation requires at least one trusted issuer")
+	}
+	return a, nil
+}
+
+// NewJWKSAuthenticator valide des jetons RS256/ES256 (et variantes) émis par un fournisseur
+// d'identité externe comme Entra ID. Émetteur et audience sont obligatoires : sans eux,
+// n'importe quel jeton signé par le fournisseur (pour une autre application) serait accepté.
+func NewJWKSAuthenticator(keys *JWKS, opts ...Option) (*Authenticator, error) {
+	a, v := newAuthenticator(keys.Keyfunc, []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}, opts)
+	if len(v.issuers) == 0 {
+		return nil, errors.New("auth: JWKS validation requires at least one trusted issuer")
+	}
+	if len(v.audiences) == 0 {
+		return nil, errors.New("auth: JWKS validation requires an audience")
+	}
+	return a, nil
+}
+
+func newAuthenticator(keyFunc jwt.Keyfunc, methods []string, opts []Option) (*Authenticator, validation) {
+	v := validation{leeway: DefaultLeeway, policy: NewPolicy(DefaultRoles)}
+	for _, opt := range opts {
+		opt(&v)
+	}
+
+	// Restreindre les algorithmes évite les attaques "alg: none" ou par confusion de clé.
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.leeway),
+	}
+	if len(v.audiences) > 0 {
+		parserOpts = append(parserOpts, jwt.WithAudience(v.audiences...))
+	}
+
+	return &Authenticator{
+		keyFunc: keyFunc,
+		parser:  jwt.NewParser(parserOpts...),
+		issuers: v.issuers,
+		policy:  v.policy,
+		apiKeys: v.apiKeys,
+	}, v
+}
+
+// Authenticate valide le jeton brut et retourne le principal correspondant.
+func (a *Authenticator) Authenticate(tokenString string) (*Principal, error) {
+	var claims Claims
+	if _, err := a.parser.ParseWithClaims(tokenString, &claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	p, err := claims.Principal()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if !a.trustedIssuer(claims.Issuer, p.TenantID) {
+		return nil, fmt.Errorf("%w: untrusted issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	p.Permissions = a.policy.Permissions(p.TenantID, p.Roles)
+	return p, nil
+}
+
+// trustedIssuer vérifie "iss" contre la liste des émetteurs de confiance (jamais vide, voir les constructeurs).
+func (a *Authenticator) trustedIssuer(iss string, tenantID string) bool {
+	for _, pattern := range a.issuers {
+		if strings.ReplaceAll(pattern, "{tenantid}", tenantID) == iss {
+			return true
 		}
+	}
+	return false
+}
 
-		// 2. Le format doit être "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid Authorization format. Expected 'Bearer <token>'", http.StatusUnauthorized)
+// Middleware exige un jeton valide ("Authorization: Bearer <token>"), ou une clé d'API si
+// WithAPIKeys est configuré, et place le Principal dans le contexte.
+// Les handlers le lisent via auth.TenantID / auth.PrincipalFrom.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var principal *Principal
+		var err error
+
+		if key, ok := apiKey(r); ok && a.apiKeys != nil {
+			principal, err = a.apiKeys.VerifyAPIKey(r.Context(), key)
+		} else if tokenString, ok := bearerToken(r); ok {
+			principal, err = a.Authenticate(tokenString)
+		} else {
+			unauthorized(w, r, ErrMissingToken)
 			return
 		}
-		tokenString := parts[1]
-
-		// 3. Parser et valider le token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Vérifier impérativement la méthode de signature (évite les attaques "none")
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			// Retourner la clé secrète pour la validation
-			return jwtSecretKey, nil
-		})
-
-		if err != nil || !token.Valid {
-			// Log l'erreur pour le debug (ne pas renvoyer l'erreur brute au client en prod)
-			fmt.Printf("Token validation failed: %v\n", err)
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+
+		if err != nil {
+			// Le détail (signature, expiration...) est utile au debug mais ne doit pas fuiter au client.
+			logger.Warn(r.Context(), "Authentication failed", "error", err)
+			unauthorized(w, r, err)
 			return
 		}
 
-		// 4. Optionnel : Extraire des claims (données utilisateur) du token et les passer au contexte
-		// if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// 	 userID := claims["sub"].(string)
-		//   // Ajouter userID au contexte de la requête (r.Context()) pour que le handler suivant puisse l'utiliser
-		// }
+		// Les logs suivants de la requête, et sa ligne d'accès, portent le tenant et l'utilisateur.
+		ctx := logger.WithRequestAttrs(r.Context(), "tenantID", principal.TenantID, "userID", principal.Subject)
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(ctx, principal)))
+	})
+}
 
-		// 5. Si tout est OK, on appelle la fonction originale
-		next.ServeHTTP(w, r)
+// apiKey extrait la clé de l'en-tête X-API-Key ou du schéma "ApiKey" de l'en-tête Authorization.
+func apiKey(r *http.Request) (string, bool) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key, true
+	}
+	scheme, key, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "ApiKey") {
+		return "", false
 	}
+	key = strings.TrimSpace(key)
+	return key, key != ""
+}
+
+// bearerToken extrait le jeton de l'en-tête Authorization (schéma insensible à la casse, RFC 7235).
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	api.RespondWithError(w, r, err)
 }
@@ -0,0 +1,135 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/auth"
+)
+
+var testSecret = []byte("test-secret")
+
+// testIssuer est l'émetteur de confiance des jetons de test.
+const testIssuer = "test-api"
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestMiddleware(t *testing.T) {
+	authenticator, err := auth.NewHMACAuthenticator(testSecret, auth.WithIssuers(testIssuer))
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name          string
+		header        string
+		expectedCode  int
+		wantPrincipal *auth.Principal
+	}{
+		{
+			name:          "Jeton valide (tid Entra ID)",
+			header:        "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"iss": testIssuer, "sub": "u-1", "tid": "tenant-A", "roles": []string{"tenant-admin"}, "exp": exp}),
+			expectedCode:  http.StatusOK,
+			wantPrincipal: &auth.Principal{Subject: "u-1", TenantID: "tenant-A", Roles: []string{"tenant-admin"}, Permissions: []string{"*"}},
+		},
+		{
+			name:          "Claim tenant et schéma en minuscules",
+			header:        "bearer " + sign(t, jwt.SigningMethodHS512, testSecret, jwt.MapClaims{"iss": testIssuer, "sub": "u-2", "tenant": "tenant-B", "exp": exp}),
+			expectedCode:  http.StatusOK,
+			wantPrincipal: &auth.Principal{Subject: "u-2", TenantID: "tenant-B"},
+		},
+		{
+			name:         "En-tête absent",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Mauvais schéma",
+			header:       "Basic dXNlcjpwYXNz",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Jeton expiré",
+			header:       "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "u-1", "tid": "tenant-A", "exp": time.Now().Add(-time.Hour).Unix()}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Sans expiration",
+			header:       "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "u-1", "tid": "tenant-A"}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Mauvaise signature",
+			header:       "Bearer " + sign(t, jwt.SigningMethodHS256, []byte("other"), jwt.MapClaims{"sub": "u-1", "tid": "tenant-A", "exp": exp}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Algorithme none refusé",
+			header:       "Bearer " + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"sub": "u-1", "tid": "tenant-A", "exp": exp}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Émetteur non configuré",
+			header:       "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"iss": "https://ailleurs.example.com", "sub": "u-1", "tid": "tenant-A", "exp": exp}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Émetteur absent",
+			header:       "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "u-1", "tid": "tenant-A", "exp": exp}),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Tenant absent",
+			header:       "Bearer " + sign(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "u-1", "exp": exp}),
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *auth.Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = auth.PrincipalFrom(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rr := httptest.NewRecorder()
+			authenticator.Middleware(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedCode, rr.Code)
+			assert.Equal(t, tc.wantPrincipal, got)
+			if tc.expectedCode == http.StatusUnauthorized {
+				assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+				// Le détail de validation reste dans les logs.
+				assert.NotContains(t, rr.Body.String(), "signature")
+			}
+		})
+	}
+}
+
+func TestNewHMACAuthenticator_RequiresIssuer(t *testing.T) {
+	_, err := auth.NewHMACAuthenticator(testSecret)
+	assert.Error(t, err, "sans émetteur de confiance, n'importe quel iss serait accepté")
+}
+
+func TestTenantID_WithoutPrincipal(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, err := auth.TenantID(req.Context())
+	assert.ErrorIs(t, err, auth.ErrNoPrincipal)
+	assert.Empty(t, auth.UserID(req.Context()))
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultAccessTokenTTL est la durée de vie des jetons d'accès émis localement.
+// Courte, car un jeton d'accès n'est pas révocable : c'est le jeton de rafraîchissement qui l'est.
+const DefaultAccessTokenTTL = 15 * time.Minute
+
+// TokenIssuer émet des jetons d'accès HS256, vérifiables par NewHMACAuthenticator avec le même secret.
+type TokenIssuer struct {
+	secret   []byte
+	issuer   string
+	audience string
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewTokenIssuer crée un émetteur. issuer et audience sont repris dans les claims "iss" et "aud"
+// (à déclarer côté validation avec WithIssuers / WithAudience).
+func NewTokenIssuer(secret []byte, issuer, audience string, ttl time.Duration) (*TokenIssuer, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("auth: empty HMAC secret")
+	}
+	if ttl <= 0 {
+		ttl = DefaultAccessTokenTTL
+	}
+	return &TokenIssuer{
+		secret:   secret,
+		issuer:   issuer,
+		audience: audience,
+		ttl:      ttl,
+		now:      time.Now,
+	}, nil
+}
+
+// Issue signe un jeton d'accès pour le principal et retourne sa date d'expiration.
+func (i *TokenIssuer) Issue(p *Principal) (string, time.Time, error) {
+	now := i.now()
+	expiresAt := now.Add(i.ttl)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   p.Subject,
+			Issuer:    i.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		TenantID: p.TenantID,
+		Roles:    p.Roles,
+	}
+	if i.audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.audience}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("auth: sign access token: %w", err)
+	}
+	return token, expiresAt, nil
+}
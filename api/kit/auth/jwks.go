@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"test-api/kit/logger"
+)
+
+// =================================================================================
+// JWKS : jeu de clés publiques (RFC 7517)
+// =================================================================================
+
+const (
+	// DefaultJWKSRefreshInterval est la fréquence de rechargement préventif des clés.
+	// Entra ID fait tourner ses clés sans préavis : un jour est la valeur recommandée par Microsoft.
+	DefaultJWKSRefreshInterval = 24 * time.Hour
+
+	// DefaultJWKSMinRefreshInterval limite les rechargements déclenchés par un "kid" inconnu,
+	// pour qu'un jeton forgé ne transforme pas chaque requête en appel au fournisseur d'identité.
+	DefaultJWKSMinRefreshInterval = time.Minute
+)
+
+// jwksFetchTimeout borne les rechargements déclenchés pendant une requête.
+const jwksFetchTimeout = 10 * time.Second
+
+// ErrUnknownKey est renvoyée quand le "kid" du jeton n'est pas dans le jeu de clés.
+var ErrUnknownKey = errors.New("auth: signing key not found in JWKS")
+
+// ErrStaleJWKS est renvoyée par Check quand les clés n'ont pas pu être rechargées à temps.
+var ErrStaleJWKS = errors.New("auth: JWKS not refreshed")
+
+// JWKS fournit les clés de vérification par identifiant ("kid").
+// Les clés viennent soit d'une URL (mise en cache, rechargée à la rotation), soit d'un fichier.
+type JWKS struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// JWKSOption configure un JWKS distant.
+type JWKSOption func(*JWKS)
+
+// WithHTTPClient remplace le client HTTP utilisé pour télécharger le document.
+func WithHTTPClient(c *http.Client) JWKSOption {
+	return func(k *JWKS) { k.client = c }
+}
+
+// WithRefreshInterval définit la durée de validité du cache.
+func WithRefreshInterval(d time.Duration) JWKSOption {
+	return func(k *JWKS) { k.refreshInterval = d }
+}
+
+// WithMinRefreshInterval définit le délai minimal entre deux téléchargements.
+func WithMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(k *JWKS) { k.minRefreshInterval = d }
+}
+
+// NewRemoteJWKS crée un jeu de clés chargé depuis url (ex : https://login.microsoftonline.com/common/discovery/v2.0/keys).
+// Le premier téléchargement a lieu ici : une URL invalide est détectée au démarrage.
+func NewRemoteJWKS(ctx context.Context, url string, opts ...JWKSOption) (*JWKS, error) {
+	k := &JWKS{
+		url:                url,
+		client:             &http.Client{Timeout: jwksFetchTimeout},
+		refreshInterval:    DefaultJWKSRefreshInterval,
+		minRefreshInterval: DefaultJWKSMinRefreshInterval,
+		now:                time.Now,
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	k.lastAttempt = k.now()
+	if err := k.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// LoadJWKSFile charge un jeu de clés statique depuis un fichier (tests, environnements hors ligne).
+func LoadJWKSFile(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read JWKS file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS construit un jeu de clés statique à partir d'un document JWKS.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return nil, err
+	}
+	return &JWKS{keys: keys, now: time.Now}, nil
+}
+
+// Keyfunc retourne la clé publique correspondant au "kid" du jeton.
+// Pour un JWKS distant, un "kid" inconnu déclenche un rechargement (rotation des clés).
+func (k *JWKS) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	if k.url != "" && k.stale() {
+		k.tryRefresh()
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	if k.url != "" && k.tryRefresh() {
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w (kid %q)", ErrUnknownKey, kid)
+}
+
+// Check vérifie la fraîcheur des clés (vérification de santé) : des clés périmées sont
+// rechargées, et une erreur est retournée si le fournisseur d'identité reste injoignable.
+// Les clés en cache restent utilisées en attendant : l'échec dégrade le service sans l'interrompre
+// (une rotation des clés ferait alors refuser les nouveaux jetons). Un jeu statique est toujours frais.
+func (k *JWKS) Check(context.Context) error {
+	if k.url == "" {
+		return nil
+	}
+	if k.stale() {
+		k.tryRefresh()
+	}
+	k.mu.RLock()
+	age := k.now().Sub(k.fetchedAt)
+	k.mu.RUnlock()
+	if age >= k.refreshInterval {
+		return fmt.Errorf("%w for %s", ErrStaleJWKS, age.Round(time.Second))
+	}
+	return nil
+}
+
+func (k *JWKS) lookup(kid string) (any, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	// Sans "kid", on n'accepte que le cas non ambigu d'un jeu à clé unique.
+	if kid == "" {
+		if len(k.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *JWKS) stale() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.now().Sub(k.fetchedAt) >= k.refreshInterval
+}
+
+// tryRefresh recharge les clés si le dernier essai est assez ancien. Elle retourne true si les clés ont changé.
+// En cas d'échec, les clés en cache restent utilisées : une panne du fournisseur ne coupe pas l'API.
+func (k *JWKS) tryRefresh() bool {
+	// Vérification et réservation sous le même verrou : une seule requête déclenche le téléchargement.
+	k.mu.Lock()
+	if k.now().Sub(k.lastAttempt) < k.minRefreshInterval {
+		k.mu.Unlock()
+		return false
+	}
+	k.lastAttempt = k.now()
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	if err := k.refresh(ctx); err != nil {
+		logger.Warn(ctx, "JWKS refresh failed, keeping cached keys", "url", k.url, "error", err)
+		return false
+	}
+	return true
+}
+
+func (k *JWKS) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return fmt.Errorf("auth: build JWKS request: %w", err)
+	}
+	res, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth: fetch JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth: fetch JWKS: unexpected status %d", res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("auth: read JWKS: %w", err)
+	}
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.fetchedAt = k.now()
+	k.mu.Unlock()
+	return nil
+}
+
+// =================================================================================
+// Décodage des clés (RSA et EC)
+// =================================================================================
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseKeySet(data []byte) (map[string]any, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// Les clés de chiffrement n'ont rien à faire dans la vérification de signature.
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("auth: JWKS key %q: %w", jwk.Kid, err)
+		}
+		if key == nil {
+			continue // type de clé non supporté (ex : oct, OKP)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("auth: JWKS contains no usable signing key")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		// Point non compressé (0x04 || X || Y) : le parseur vérifie qu'il est bien sur la courbe.
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) > size || len(y) > size {
+			return nil, errors.New("invalid point")
+		}
+		point := make([]byte, 1+2*size)
+		point[0] = 4
+		copy(point[1+size-len(x):1+size], x)
+		copy(point[1+2*size-len(y):], y)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
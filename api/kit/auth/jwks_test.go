@@ -0,0 +1,227 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/auth"
+)
+
+const (
+	testAudience = "api://erp"
+	entraIssuer  = "https://login.microsoftonline.com/{tenantid}/v2.0"
+)
+
+// testKey est une clé de signature et son entrée JWKS publique.
+type testKey struct {
+	kid    string
+	method jwt.SigningMethod
+	signer any
+	jwk    map[string]string
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return testKey{kid: kid, method: jwt.SigningMethodRS256, signer: k, jwk: map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes()),
+	}}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	point, err := k.PublicKey.Bytes() // 0x04 || X || Y
+	require.NoError(t, err)
+	return testKey{kid: kid, method: jwt.SigningMethodES256, signer: k, jwk: map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(point[1:33]), "y": b64(point[33:]),
+	}}
+}
+
+func jwksDocument(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+	doc := map[string]any{"keys": []map[string]string{}}
+	for _, k := range keys {
+		doc["keys"] = append(doc["keys"].([]map[string]string), k.jwk)
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	return data
+}
+
+func (k testKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	s, err := token.SignedString(k.signer)
+	require.NoError(t, err)
+	return s
+}
+
+func entraClaims(tenantID string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"tid": tenantID,
+		"aud": testAudience,
+		"iss": "https://login.microsoftonline.com/" + tenantID + "/v2.0",
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWKSAuthenticator_Validation(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwksDocument(t, rsaKey, ecKey), 0o600))
+	keys, err := auth.LoadJWKSFile(path)
+	require.NoError(t, err)
+
+	authenticator, err := auth.NewJWKSAuthenticator(keys,
+		auth.WithIssuers(entraIssuer, "https://issuer.example.com"),
+		auth.WithAudience(testAudience),
+		auth.WithLeeway(30*time.Second),
+	)
+	require.NoError(t, err)
+
+	with := func(overrides jwt.MapClaims) jwt.MapClaims {
+		claims := entraClaims("tenant-A")
+		for k, v := range overrides {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	testCases := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "RS256 valide", token: rsaKey.sign(t, with(nil))},
+		{name: "ES256 valide", token: ecKey.sign(t, with(nil))},
+		{name: "Autre tenant via le motif {tenantid}", token: rsaKey.sign(t, entraClaims("tenant-B"))},
+		{name: "Second émetteur de confiance", token: rsaKey.sign(t, with(jwt.MapClaims{"iss": "https://issuer.example.com"}))},
+		{name: "Décalage d'horloge toléré", token: rsaKey.sign(t, with(jwt.MapClaims{"nbf": time.Now().Add(20 * time.Second).Unix()}))},
+		{name: "Pas encore valide", token: rsaKey.sign(t, with(jwt.MapClaims{"nbf": time.Now().Add(time.Minute).Unix()})), wantErr: true},
+		{name: "Expiré", token: rsaKey.sign(t, with(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})), wantErr: true},
+		{name: "Mauvaise audience", token: rsaKey.sign(t, with(jwt.MapClaims{"aud": "api://other"})), wantErr: true},
+		{name: "Émetteur inconnu", token: rsaKey.sign(t, with(jwt.MapClaims{"iss": "https://evil.example.com"})), wantErr: true},
+		{name: "Émetteur d'un autre tenant", token: rsaKey.sign(t, with(jwt.MapClaims{"iss": "https://login.microsoftonline.com/tenant-B/v2.0"})), wantErr: true},
+		{name: "Kid inconnu", token: newRSAKey(t, "rogue").sign(t, with(nil)), wantErr: true},
+		{name: "HMAC refusé", token: sign(t, jwt.SigningMethodHS256, []byte("secret"), with(nil)), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := authenticator.Authenticate(tc.token)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, auth.ErrInvalidToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", p.Subject)
+			assert.NotEmpty(t, p.TenantID)
+		})
+	}
+}
+
+func TestNewJWKSAuthenticator_RequiresIssuerAndAudience(t *testing.T) {
+	keys, err := auth.ParseJWKS(jwksDocument(t, newECKey(t, "ec-1")))
+	require.NoError(t, err)
+
+	_, err = auth.NewJWKSAuthenticator(keys, auth.WithAudience(testAudience))
+	assert.Error(t, err)
+	_, err = auth.NewJWKSAuthenticator(keys, auth.WithIssuers(entraIssuer))
+	assert.Error(t, err)
+}
+
+func TestRemoteJWKS_KeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "2025")
+	newKey := newECKey(t, "2026")
+
+	var mu sync.Mutex
+	served := jwksDocument(t, oldKey)
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		w.Write(served)
+	}))
+	defer srv.Close()
+
+	keys, err := auth.NewRemoteJWKS(t.Context(), srv.URL, auth.WithMinRefreshInterval(0))
+	require.NoError(t, err)
+	authenticator, err := auth.NewJWKSAuthenticator(keys, auth.WithIssuers(entraIssuer), auth.WithAudience(testAudience))
+	require.NoError(t, err)
+
+	_, err = authenticator.Authenticate(oldKey.sign(t, entraClaims("tenant-A")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetches, "les clés sont servies depuis le cache")
+
+	// Le fournisseur publie une nouvelle clé : le kid inconnu déclenche un rechargement.
+	mu.Lock()
+	served = jwksDocument(t, newKey)
+	mu.Unlock()
+
+	_, err = authenticator.Authenticate(newKey.sign(t, entraClaims("tenant-A")))
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches)
+
+	// L'ancienne clé a été retirée du document : elle n'est plus acceptée.
+	_, err = authenticator.Authenticate(oldKey.sign(t, entraClaims("tenant-A")))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestRemoteJWKS_Check(t *testing.T) {
+	key := newRSAKey(t, "2025")
+	var available atomic.Bool
+	available.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(jwksDocument(t, key))
+	}))
+	defer srv.Close()
+
+	keys, err := auth.NewRemoteJWKS(t.Context(), srv.URL, auth.WithRefreshInterval(50*time.Millisecond), auth.WithMinRefreshInterval(0))
+	require.NoError(t, err)
+	require.NoError(t, keys.Check(t.Context()))
+
+	// Fournisseur injoignable : les clés vieillissent, la vérification le signale.
+	available.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	assert.ErrorIs(t, keys.Check(t.Context()), auth.ErrStaleJWKS)
+
+	// Retour du fournisseur : la vérification recharge les clés.
+	available.Store(true)
+	assert.NoError(t, keys.Check(t.Context()))
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// =================================================================================
+// Jetons opaques (clés d'API, jetons de rafraîchissement)
+// =================================================================================
+
+// OpaqueToken est un secret remis au client sous la forme "<préfixe><tenant en base64url>.<id>.<secret>".
+// Le tenant et l'id permettent une lecture directe dans la bonne partition, sans requête transverse ;
+// seule l'empreinte du secret (Hash) est stockée.
+type OpaqueToken struct {
+	TenantID string
+	ID       string
+	Secret   string
+}
+
+// NewOpaqueToken génère un secret aléatoire (256 bits) pour l'enregistrement id du tenant.
+func NewOpaqueToken(tenantID, id string) (OpaqueToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return OpaqueToken{}, fmt.Errorf("auth: generate token secret: %w", err)
+	}
+	return OpaqueToken{TenantID: tenantID, ID: id, Secret: base64.RawURLEncoding.EncodeToString(secret)}, nil
+}
+
+// ParseOpaqueToken découpe un jeton produit par Format avec le même préfixe.
+// ok vaut false si le jeton est malformé (préfixe, tenant, id non UUID, secret vide).
+func ParseOpaqueToken(prefix, raw string) (token OpaqueToken, ok bool) {
+	rest, found := strings.CutPrefix(raw, prefix)
+	if !found {
+		return OpaqueToken{}, false
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return OpaqueToken{}, false
+	}
+	tenant, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(tenant) == 0 {
+		return OpaqueToken{}, false
+	}
+	if _, err := uuid.Parse(parts[1]); err != nil || parts[2] == "" {
+		return OpaqueToken{}, false
+	}
+	return OpaqueToken{TenantID: string(tenant), ID: parts[1], Secret: parts[2]}, true
+}
+
+// Format produit la forme remise au client.
+func (t OpaqueToken) Format(prefix string) string {
+	return prefix + base64.RawURLEncoding.EncodeToString([]byte(t.TenantID)) + "." + t.ID + "." + t.Secret
+}
+
+// Hash retourne l'empreinte du secret, à stocker à la place du secret.
+func (t OpaqueToken) Hash() string {
+	sum := sha256.Sum256([]byte(t.Secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches compare (en temps constant) le secret à une empreinte stockée.
+func (t OpaqueToken) Matches(hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(t.Hash()), []byte(hash)) == 1
+}
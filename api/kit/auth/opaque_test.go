@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/auth"
+)
+
+func TestOpaqueToken_RoundTrip(t *testing.T) {
+	token, err := auth.NewOpaqueToken("tenant-A", uuid.NewString())
+	require.NoError(t, err)
+
+	raw := token.Format("erp_")
+	assert.True(t, strings.HasPrefix(raw, "erp_"))
+	assert.NotContains(t, raw, "tenant-A", "le tenant est encodé")
+
+	parsed, ok := auth.ParseOpaqueToken("erp_", raw)
+	require.True(t, ok)
+	assert.Equal(t, token, parsed)
+
+	hash := token.Hash()
+	assert.NotContains(t, hash, token.Secret)
+	assert.True(t, parsed.Matches(hash))
+
+	other, err := auth.NewOpaqueToken("tenant-A", token.ID)
+	require.NoError(t, err)
+	assert.False(t, other.Matches(hash), "chaque jeton a son propre secret")
+}
+
+func TestParseOpaqueToken_Malformed(t *testing.T) {
+	token, err := auth.NewOpaqueToken("tenant-A", uuid.NewString())
+	require.NoError(t, err)
+	raw := token.Format("erp_")
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"Autre préfixe", token.Format("rt_")},
+		{"Sans préfixe", strings.TrimPrefix(raw, "erp_")},
+		{"Partie manquante", raw[:strings.LastIndex(raw, ".")]},
+		{"Partie en trop", raw + ".x"},
+		{"Tenant vide", "erp_." + token.ID + "." + token.Secret},
+		{"Tenant non base64", "erp_!!." + token.ID + "." + token.Secret},
+		{"Id non UUID", strings.Replace(raw, token.ID, "42", 1)},
+		{"Secret vide", strings.TrimSuffix(raw, token.Secret)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok := auth.ParseOpaqueToken("erp_", tc.raw)
+			assert.False(t, ok)
+		})
+	}
+}
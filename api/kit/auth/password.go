@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// =================================================================================
+// Hachage des mots de passe
+// =================================================================================
+
+// Paramètres argon2id (recommandation OWASP : 64 Mio, 3 passes). Les empreintes sont au
+// format PHC ($argon2id$v=19$m=...,t=...,p=...$sel$hash) : les paramètres voyagent avec
+// l'empreinte, on peut donc les durcir sans invalider les mots de passe existants.
+const (
+	argonMemory  = 64 * 1024
+	argonTime    = 3
+	argonThreads = 2
+	argonSaltLen = 16
+	argonKeyLen  = 32
+)
+
+// ErrUnsupportedHash est renvoyée pour une empreinte dont le format n'est pas reconnu.
+var ErrUnsupportedHash = errors.New("auth: unsupported password hash format")
+
+// HashPassword calcule l'empreinte argon2id d'un mot de passe.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("auth: generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword compare un mot de passe à son empreinte (argon2id, ou bcrypt pour les
+// comptes importés). needsRehash signale une empreinte à recalculer avec HashPassword
+// (bcrypt ou paramètres argon2 obsolètes) après une connexion réussie.
+func VerifyPassword(hash, password string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, fmt.Errorf("auth: bcrypt: %w", err)
+		}
+		return true, true, nil
+	default:
+		return false, false, ErrUnsupportedHash
+	}
+}
+
+func verifyArgon2id(hash, password string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", sel, hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnsupportedHash
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnsupportedHash
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return false, false, ErrUnsupportedHash
+	}
+
+	key := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return false, false, nil
+	}
+	needsRehash := memory != argonMemory || time != argonTime || threads != argonThreads || len(expected) != argonKeyLen
+	return true, needsRehash, nil
+}
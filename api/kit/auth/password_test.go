@@ -0,0 +1,76 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"test-api/kit/auth"
+)
+
+func TestHashPassword_Argon2id(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$"))
+
+	ok, needsRehash, err := auth.VerifyPassword(hash, "correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	ok, _, err = auth.VerifyPassword(hash, "Correct horse battery staple")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Deux empreintes du même mot de passe diffèrent (sel aléatoire).
+	other, err := auth.HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestVerifyPassword_LegacyHashes(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("imported-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, needsRehash, err := auth.VerifyPassword(string(legacy), "imported-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash, "bcrypt doit être migré vers argon2id")
+
+	// Paramètres argon2 plus faibles que les paramètres courants.
+	salt := []byte("saltsaltsaltsalt")
+	key := argon2.IDKey([]byte("old-password"), salt, 1, 16, 1, 32)
+	weak := fmt.Sprintf("$argon2id$v=19$m=16,t=1,p=1$%s$%s",
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	ok, needsRehash, err = auth.VerifyPassword(weak, "old-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	_, _, err = auth.VerifyPassword("md5:5f4dcc3b5aa765d61d8327deb882cf99", "password")
+	assert.ErrorIs(t, err, auth.ErrUnsupportedHash)
+}
+
+func TestTokenIssuer_RoundTrip(t *testing.T) {
+	issuer, err := auth.NewTokenIssuer(testSecret, "test-api", "erp", 0)
+	require.NoError(t, err)
+	authenticator, err := auth.NewHMACAuthenticator(testSecret, auth.WithIssuers("test-api"), auth.WithAudience("erp"))
+	require.NoError(t, err)
+
+	token, expiresAt, err := issuer.Issue(&auth.Principal{Subject: "u-1", TenantID: "tenant-A", Roles: []string{auth.RoleManager}})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(auth.DefaultAccessTokenTTL), expiresAt, 5*time.Second)
+
+	p, err := authenticator.Authenticate(token)
+	require.NoError(t, err)
+	assert.Equal(t, "u-1", p.Subject)
+	assert.Equal(t, "tenant-A", p.TenantID)
+	assert.True(t, p.Can("users:write"))
+}
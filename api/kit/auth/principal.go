@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"test-api/kit/apperr"
+)
+
+// Principal est l'identité authentifiée à l'origine de la requête.
+// Il est construit par le middleware à partir des claims du jeton puis placé dans le contexte.
+type Principal struct {
+	// Subject est l'identifiant stable de l'utilisateur (claim "sub").
+	Subject string `json:"sub"`
+	// TenantID est le tenant auquel la requête est rattachée (claim "tid" ou "tenant").
+	TenantID string `json:"tenantID"`
+	// Roles sont les rôles accordés dans ce tenant (claim "roles").
+	Roles []string `json:"roles,omitempty"`
+	// Permissions sont déduites des rôles par la Policy du tenant (voir Can).
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// HasRole indique si le principal possède le rôle donné.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// ErrNoPrincipal est renvoyée quand aucune identité n'a été placée dans le contexte
+// (route non protégée par le middleware, ou test qui a oublié de l'injecter).
+var ErrNoPrincipal = apperr.New(apperr.ErrUnauthorized, "authentication required")
+
+// Clé privée : seul ce package peut écrire le principal dans le contexte.
+type principalKey struct{}
+
+// WithPrincipal retourne un contexte portant le principal.
+// Utilisé par le middleware, et par les tests pour simuler une requête authentifiée.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom retourne le principal de la requête, s'il y en a un.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// TenantID retourne le tenant du principal courant.
+// L'erreur (401) peut être passée telle quelle à api.RespondWithError.
+func TenantID(ctx context.Context) (string, error) {
+	p, ok := PrincipalFrom(ctx)
+	if !ok || p.TenantID == "" {
+		return "", ErrNoPrincipal
+	}
+	return p.TenantID, nil
+}
+
+// UserID retourne l'identifiant du principal courant ("" si la requête n'est pas authentifiée).
+func UserID(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.Subject
+	}
+	return ""
+}
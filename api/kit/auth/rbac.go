@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+)
+
+// =================================================================================
+// Rôles et permissions
+// =================================================================================
+
+// Une permission a la forme "<ressource>:<action>" (ex : "users:write").
+// "*" accorde tout, "users:*" toutes les actions sur une ressource.
+
+// Rôles standards d'un tenant.
+const (
+	RoleTenantAdmin = "tenant-admin"
+	RoleManager     = "manager"
+	RoleMember      = "member"
+)
+
+// RoleMapping associe chaque rôle aux permissions qu'il accorde.
+type RoleMapping map[string][]string
+
+// DefaultRoles est la correspondance appliquée aux tenants qui n'en définissent pas.
+var DefaultRoles = RoleMapping{
+	RoleTenantAdmin: {"*"},
+	RoleManager:     {"users:read", "users:write"},
+	RoleMember:      {"users:read"},
+}
+
+// ErrForbidden est renvoyée quand le principal n'a pas la permission requise.
+var ErrForbidden = apperr.New(apperr.ErrForbidden, "insufficient permissions")
+
+// Policy résout les permissions d'un principal selon son tenant.
+// Un tenant peut remplacer la correspondance par défaut (rôles propres, droits réduits...).
+type Policy struct {
+	mu       sync.RWMutex
+	defaults RoleMapping
+	tenants  map[string]RoleMapping
+}
+
+// NewPolicy crée une politique avec la correspondance par défaut donnée.
+func NewPolicy(defaults RoleMapping) *Policy {
+	return &Policy{
+		defaults: defaults,
+		tenants:  make(map[string]RoleMapping),
+	}
+}
+
+// LoadPolicyFile charge une politique depuis un fichier JSON :
+//
+//	{"default": {"member": ["users:read"]}, "tenants": {"tenant-A": {"member": []}}}
+//
+// Sans section "default", DefaultRoles s'applique.
+func LoadPolicyFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read policy file: %w", err)
+	}
+	var doc struct {
+		Default RoleMapping            `json:"default"`
+		Tenants map[string]RoleMapping `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("auth: decode policy file: %w", err)
+	}
+	if doc.Default == nil {
+		doc.Default = DefaultRoles
+	}
+	p := NewPolicy(doc.Default)
+	for tenantID, roles := range doc.Tenants {
+		p.SetTenantRoles(tenantID, roles)
+	}
+	return p, nil
+}
+
+// SetTenantRoles remplace la correspondance rôles/permissions d'un tenant.
+func (p *Policy) SetTenantRoles(tenantID string, roles RoleMapping) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tenants[tenantID] = maps.Clone(roles)
+}
+
+// Permissions retourne les permissions accordées par les rôles dans ce tenant.
+func (p *Policy) Permissions(tenantID string, roles []string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	mapping, ok := p.tenants[tenantID]
+	if !ok {
+		mapping = p.defaults
+	}
+
+	var perms []string
+	for _, role := range roles {
+		for _, perm := range mapping[role] {
+			if !slices.Contains(perms, perm) {
+				perms = append(perms, perm)
+			}
+		}
+	}
+	return perms
+}
+
+// Can indique si le principal possède la permission (directement ou par joker).
+func (p *Principal) Can(permission string) bool {
+	resource, _, _ := strings.Cut(permission, ":")
+	for _, granted := range p.Permissions {
+		if granted == "*" || granted == permission || granted == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Require est un middleware chi qui exige une permission :
+//
+//	r.With(auth.Require("users:write")).Post("/", h.Create)
+//
+// Il suppose que le middleware d'authentification a déjà placé le Principal dans le contexte
+// (401 sinon) et répond 403 si la permission manque.
+func Require(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFrom(r.Context())
+			if !ok {
+				api.RespondWithError(w, r, ErrNoPrincipal)
+				return
+			}
+			if !p.Can(permission) {
+				api.RespondWithError(w, r, fmt.Errorf("%w: %s requires %q", ErrForbidden, p.Subject, permission))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package auth_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/auth"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		permission  string
+		want        bool
+	}{
+		{"Permission exacte", []string{"users:read"}, "users:read", true},
+		{"Autre action", []string{"users:read"}, "users:write", false},
+		{"Joker de ressource", []string{"users:*"}, "users:write", true},
+		{"Joker d'une autre ressource", []string{"products:*"}, "users:write", false},
+		{"Joker global", []string{"*"}, "users:write", true},
+		{"Aucune permission", nil, "users:read", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &auth.Principal{Permissions: tc.permissions}
+			assert.Equal(t, tc.want, p.Can(tc.permission))
+		})
+	}
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roles.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"tenants": {
+			"tenant-A": {"member": ["users:read", "users:write"], "auditor": ["users:read"]}
+		}
+	}`), 0o600))
+
+	policy, err := auth.LoadPolicyFile(path)
+	require.NoError(t, err)
+
+	// Le tenant A a sa propre correspondance...
+	assert.ElementsMatch(t, []string{"users:read", "users:write"}, policy.Permissions("tenant-A", []string{"member", "auditor"}))
+	assert.Empty(t, policy.Permissions("tenant-A", []string{auth.RoleTenantAdmin}))
+	// ... les autres tenants gardent DefaultRoles.
+	assert.Equal(t, []string{"users:read"}, policy.Permissions("tenant-B", []string{auth.RoleMember}))
+}
@@ -0,0 +1,62 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/database"
+	"test-api/kit/database/databasetest"
+)
+
+// emulatorKey est la clé publique et fixe de l'émulateur Cosmos (documentée par Microsoft).
+const emulatorKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+// TestConformance exécute la suite de conformité contre l'émulateur Cosmos.
+// Ignoré sauf si COSMOS_EMULATOR_ENDPOINT est défini (ex: https://localhost:8081).
+func TestConformance(t *testing.T) {
+	endpoint := os.Getenv("COSMOS_EMULATOR_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("COSMOS_EMULATOR_ENDPOINT non défini : test contre l'émulateur Cosmos ignoré")
+	}
+	key := os.Getenv("COSMOS_EMULATOR_KEY")
+	if key == "" {
+		key = emulatorKey
+	}
+
+	ctx := context.Background()
+	cred, err := azcosmos.NewKeyCredential(key)
+	require.NoError(t, err)
+	client, err := azcosmos.NewClientWithKey(endpoint, cred, nil)
+	require.NoError(t, err)
+
+	// Création idempotente de la base et du conteneur de test (partitionné sur /tenantID).
+	const dbName, containerName = "ConformanceDB", "Items"
+	_, err = client.CreateDatabase(ctx, azcosmos.DatabaseProperties{ID: dbName}, nil)
+	require.NoError(t, ignoreConflict(err))
+	db, err := client.NewDatabase(dbName)
+	require.NoError(t, err)
+	_, err = db.CreateContainer(ctx, azcosmos.ContainerProperties{
+		ID:                     containerName,
+		PartitionKeyDefinition: azcosmos.PartitionKeyDefinition{Paths: []string{"/tenantID"}},
+	}, nil)
+	require.NoError(t, ignoreConflict(err))
+
+	adapter, err := NewAdapter[databasetest.Item](client, dbName, containerName)
+	require.NoError(t, err)
+
+	databasetest.RunRepositorySuite(t, func(t *testing.T) database.Repository[databasetest.Item] {
+		return adapter
+	})
+}
+
+func ignoreConflict(err error) error {
+	if errors.Is(MapError(err), database.ErrConflict) {
+		return nil
+	}
+	return err
+}
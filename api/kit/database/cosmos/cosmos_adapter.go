@@ -7,12 +7,15 @@ import (
 
 	"test-api/kit/database"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 )
 
 // Adapter implémente database.Repository pour Cosmos DB.
 type Adapter[T database.Entity] struct {
-	container *azcosmos.ContainerClient
+	container     *azcosmos.ContainerClient
+	dbName        string
+	containerName string
 }
 
 func (a *Adapter[T]) Container() *azcosmos.ContainerClient {
@@ -32,20 +35,30 @@ func NewAdapter[T database.Entity](client *azcosmos.Client, dbName, containerNam
 	}
 
 	return &Adapter[T]{
-		container: container,
+		container:     container,
+		dbName:        dbName,
+		containerName: containerName,
 	}, nil
 }
 
-func (a *Adapter[T]) Create(ctx context.Context, item T) error {
+func (a *Adapter[T]) Create(ctx context.Context, item T) (T, error) {
 	pk := azcosmos.NewPartitionKeyString(item.GetTenantID())
 
 	b, err := json.Marshal(item)
 	if err != nil {
-		return err
+		return item, err
+	}
+
+	ctx, op := a.startOperation(ctx, "CreateItem", item.GetTenantID())
+	res, err := a.container.CreateItem(ctx, pk, b, nil)
+	op.addCharge(res.RequestCharge)
+	op.end(err)
+	if err != nil {
+		return item, MapError(err)
 	}
 
-	_, err = a.container.CreateItem(ctx, pk, b, nil)
-	return err
+	database.SetETag(&item, string(res.ETag))
+	return item, nil
 }
 
 func (a *Adapter[T]) Read(ctx context.Context, id string, partitionKey string) (T, error) {
@@ -55,95 +68,98 @@ func (a *Adapter[T]) Read(ctx context.Context, id string, partitionKey string) (
 
 	pk := azcosmos.NewPartitionKeyString(partitionKey)
 
+	ctx, op := a.startOperation(ctx, "ReadItem", partitionKey)
 	res, err := a.container.ReadItem(ctx, pk, id, nil)
+	op.addCharge(res.RequestCharge)
+	op.end(err)
 	if err != nil {
+		return item, MapError(err)
+	}
+
+	if err := json.Unmarshal(res.Value, &item); err != nil {
 		return item, err
 	}
 
-	err = json.Unmarshal(res.Value, &item)
-	return item, err
+	database.SetETag(&item, string(res.ETag))
+	return item, nil
 }
 
-func (a *Adapter[T]) Update(ctx context.Context, item T) error {
+func (a *Adapter[T]) Update(ctx context.Context, item T, opts ...database.WriteOption) (T, error) {
 	pk := azcosmos.NewPartitionKeyString(item.GetTenantID())
 
 	b, err := json.Marshal(item)
 	if err != nil {
-		return err
+		return item, err
 	}
 
-	// ReplaceItem écrase l'élément existant
-	_, err = a.container.ReplaceItem(ctx, pk, item.GetID(), b, nil)
-	return err
-}
+	// ReplaceItem écrase l'élément existant, sauf si l'ETag ne correspond plus (412).
+	ctx, op := a.startOperation(ctx, "ReplaceItem", item.GetTenantID())
+	res, err := a.container.ReplaceItem(ctx, pk, item.GetID(), b, itemOptions(database.ApplyWriteOptions(&item, opts)))
+	op.addCharge(res.RequestCharge)
+	op.end(err)
+	if err != nil {
+		return item, MapError(err)
+	}
 
-func (a *Adapter[T]) Delete(ctx context.Context, id string, partitionKey string) error {
-	pk := azcosmos.NewPartitionKeyString(partitionKey)
-	_, err := a.container.DeleteItem(ctx, pk, id, nil)
-	return err
+	database.SetETag(&item, string(res.ETag))
+	return item, nil
 }
 
-// TODO à tester et le faire de façon générique car actuellement les filtres sont spécifiques à User
-func (a *Adapter[T]) Search(ctx context.Context, filter database.UserFilter, partitionKey string) ([]T, error) {
+func (a *Adapter[T]) Delete(ctx context.Context, id string, partitionKey string, opts ...database.WriteOption) error {
 	pk := azcosmos.NewPartitionKeyString(partitionKey)
+	ctx, op := a.startOperation(ctx, "DeleteItem", partitionKey)
+	res, err := a.container.DeleteItem(ctx, pk, id, itemOptions(database.ApplyWriteOptions[T](nil, opts)))
+	op.addCharge(res.RequestCharge)
+	op.end(err)
+	return MapError(err)
+}
 
-	query := "SELECT * FROM c WHERE 1=1" // 1=1 permet d'ajouter des AND facilement
-	var params []azcosmos.QueryParameter
-
-	if filter.Category != nil {
-		query += " AND c.category = @category"
-		params = append(params, azcosmos.QueryParameter{Name: "@category", Value: *filter.Category})
-	}
-
-	if filter.MinAge != nil {
-		query += " AND c.age >= @minAge"
-		params = append(params, azcosmos.QueryParameter{Name: "@minAge", Value: *filter.MinAge})
-	}
-
-	if filter.IsActive != nil {
-		query += " AND c.isActive = @isActive"
-		params = append(params, azcosmos.QueryParameter{Name: "@isActive", Value: *filter.IsActive})
+// itemOptions traduit les préconditions génériques en options du SDK Cosmos.
+func itemOptions(o database.WriteOptions) *azcosmos.ItemOptions {
+	if o.IfMatch == "" {
+		return nil
 	}
+	etag := azcore.ETag(o.IfMatch)
+	return &azcosmos.ItemOptions{IfMatchEtag: &etag}
+}
 
-	// Ajout pagination (OFFSET LIMIT est supporté par Cosmos DB moderne)
-	if filter.Limit > 0 {
-		query += " OFFSET @offset LIMIT @limit"
-		params = append(params, azcosmos.QueryParameter{Name: "@offset", Value: filter.Offset})
-		params = append(params, azcosmos.QueryParameter{Name: "@limit", Value: filter.Limit})
-	}
+// Search exécute une requête générique dans une partition.
+// La requête est compilée en SQL Cosmos paramétré (voir query.go).
+// Si query.Limit > 0, une seule page est lue et son jeton de continuation est renvoyé ;
+// sinon toutes les pages sont parcourues.
+func (a *Adapter[T]) Search(ctx context.Context, query database.Query[T], partitionKey string) (database.Page[T], error) {
+	pk := azcosmos.NewPartitionKeyString(partitionKey)
 
-	// Exécution avec le SDK Cosmos...
-	// --- CORRECTION 1 : ORDER BY OBLIGATOIRE ---
-	// Cosmos DB exige un ORDER BY pour utiliser OFFSET/LIMIT.
-	// Ici je trie par ID, mais ça pourrait être c.createdAt DESC
-	if filter.Limit > 0 {
-		query += " ORDER BY c.id ASC OFFSET @offset LIMIT @limit"
-		params = append(params, azcosmos.QueryParameter{Name: "@offset", Value: filter.Offset})
-		params = append(params, azcosmos.QueryParameter{Name: "@limit", Value: filter.Limit})
+	compiled, err := compileQuery(query)
+	if err != nil {
+		return database.Page[T]{}, err
 	}
 
-	// Préparation des options
 	queryOptions := azcosmos.QueryOptions{
-		QueryParameters: params,
+		QueryParameters: compiled.params,
+		PageSizeHint:    int32(query.Limit),
+	}
+	if query.Continuation != "" {
+		queryOptions.ContinuationToken = &query.Continuation
 	}
 
-	// --- CORRECTION 2 : Optimisation Partition Key ---
-	// Si "Category" est ta partition key, il faut l'ajouter ici pour éviter un scan complet
-	// if filter.Category != nil {
-	// 	 queryOptions.PartitionKey = azcosmos.NewPartitionKeyString(*filter.Category)
-	// }
+	// Un seul span pour toute la recherche : le coût en RU est cumulé sur les pages lues.
+	ctx, op := a.startOperation(ctx, "QueryItems", partitionKey)
+	var queryErr error
+	defer func() { op.end(queryErr) }()
 
 	// Création du Pager
-	pager := a.container.NewQueryItemsPager(query, pk, &queryOptions)
+	pager := a.container.NewQueryItemsPager(compiled.text, pk, &queryOptions)
 
-	var results []T
+	var page database.Page[T]
 
-	// --- CORRECTION 3 : Boucle et Désérialisation ---
 	for pager.More() {
 		// Récupération de la page (appel réseau)
 		response, err := pager.NextPage(ctx)
+		op.addCharge(response.RequestCharge)
 		if err != nil {
-			return nil, fmt.Errorf("erreur lors de la requête cosmos: %w", err)
+			queryErr = err
+			return database.Page[T]{}, fmt.Errorf("erreur lors de la requête cosmos: %w", MapError(err))
 		}
 
 		// Chaque réponse contient une liste d'items sous forme de []byte (JSON brut)
@@ -151,11 +167,23 @@ func (a *Adapter[T]) Search(ctx context.Context, filter database.UserFilter, par
 			var item T
 			// On transforme le JSON en struct Go T
 			if err := json.Unmarshal(bytes, &item); err != nil {
-				return nil, fmt.Errorf("erreur de désérialisation: %w", err)
+				// Le span et les métriques doivent refléter l'échec, comme pour NextPage.
+				queryErr = err
+				return database.Page[T]{}, fmt.Errorf("erreur de désérialisation: %w", err)
 			}
-			results = append(results, item)
+			page.Items = append(page.Items, item)
+		}
+
+		page.Continuation = ""
+		if response.ContinuationToken != nil {
+			page.Continuation = *response.ContinuationToken
+		}
+
+		// Cosmos peut renvoyer une page vide avec un jeton : on continue jusqu'à avoir des résultats.
+		if query.Limit > 0 && len(page.Items) > 0 {
+			break
 		}
 	}
 
-	return results, nil
+	return page, nil
 }
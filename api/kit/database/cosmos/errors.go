@@ -0,0 +1,43 @@
+package cosmos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+
+	"test-api/kit/database"
+)
+
+// MapError traduit une erreur du SDK Cosmos en sentinelle de kit/database.
+// L'erreur d'origine reste dans la chaîne (errors.As(err, &azcore.ResponseError) fonctionne toujours
+// pour le debug), mais les domaines n'ont plus besoin d'importer azcore.
+func MapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var responseErr *azcore.ResponseError
+	if !errors.As(err, &responseErr) {
+		return err
+	}
+
+	var sentinel error
+	switch responseErr.StatusCode {
+	case http.StatusNotFound:
+		sentinel = database.ErrNotFound
+	case http.StatusConflict:
+		sentinel = database.ErrConflict
+	case http.StatusPreconditionFailed:
+		sentinel = database.ErrPreconditionFailed
+	case http.StatusTooManyRequests:
+		sentinel = database.ErrThrottled
+	case http.StatusUnauthorized, http.StatusForbidden:
+		sentinel = database.ErrUnauthorized
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
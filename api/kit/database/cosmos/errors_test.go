@@ -0,0 +1,54 @@
+package cosmos
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+
+	"test-api/kit/apperr"
+	"test-api/kit/database"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected error
+	}{
+		{http.StatusNotFound, database.ErrNotFound},
+		{http.StatusConflict, database.ErrConflict},
+		{http.StatusPreconditionFailed, database.ErrPreconditionFailed},
+		{http.StatusTooManyRequests, database.ErrThrottled},
+		{http.StatusUnauthorized, database.ErrUnauthorized},
+		{http.StatusForbidden, database.ErrUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			raw := fmt.Errorf("sdk call: %w", &azcore.ResponseError{StatusCode: tc.status})
+
+			err := MapError(raw)
+
+			assert.ErrorIs(t, err, tc.expected)
+			// L'erreur d'origine reste accessible pour le debug.
+			var responseErr *azcore.ResponseError
+			assert.True(t, errors.As(err, &responseErr))
+			assert.Equal(t, tc.status, responseErr.StatusCode)
+		})
+	}
+
+	t.Run("unauthorized is not the client's fault", func(t *testing.T) {
+		err := MapError(&azcore.ResponseError{StatusCode: http.StatusForbidden})
+		assert.ErrorIs(t, err, apperr.ErrInternal)
+		assert.NotErrorIs(t, err, apperr.ErrUnauthorized)
+	})
+
+	t.Run("unknown errors are returned unchanged", func(t *testing.T) {
+		raw := &azcore.ResponseError{StatusCode: http.StatusServiceUnavailable}
+		assert.Same(t, raw, MapError(raw))
+		assert.NoError(t, MapError(nil))
+	})
+}
@@ -0,0 +1,50 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+// healthItemID désigne un document qui n'existe pas : sa lecture répond 404 pour environ 1 RU,
+// la lecture la moins chère qui prouve que le compte, les droits et le conteneur sont en place.
+const healthItemID = "__health"
+
+// subStatusOwnerNotFound accompagne un 404 quand c'est le conteneur (ou la base) qui n'existe pas.
+const subStatusOwnerNotFound = "1003"
+
+// Ping vérifie que le conteneur est joignable (vérification de santé, voir kit/health).
+// L'appel n'est ni tracé ni compté dans les métriques : les sondes fausseraient le coût par tenant.
+func (a *Adapter[T]) Ping(ctx context.Context) error {
+	pk := azcosmos.NewPartitionKeyString(healthItemID)
+	_, err := a.container.ReadItem(ctx, pk, healthItemID, nil)
+	return a.pingResult(err)
+}
+
+// pingResult interprète la réponse de Ping. Le message reste court : il est affiché par les
+// sondes, alors que l'erreur du SDK contient l'URL du compte.
+func (a *Adapter[T]) pingResult(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var responseErr *azcore.ResponseError
+	if !errors.As(err, &responseErr) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("cosmos: container %s: %w", a.containerName, err)
+		}
+		return fmt.Errorf("cosmos: container %s unreachable", a.containerName)
+	}
+
+	if responseErr.StatusCode == http.StatusNotFound {
+		if responseErr.RawResponse == nil || responseErr.RawResponse.Header.Get("x-ms-substatus") != subStatusOwnerNotFound {
+			return nil
+		}
+		return fmt.Errorf("cosmos: container %s not found", a.containerName)
+	}
+	return fmt.Errorf("cosmos: container %s unavailable (status %d)", a.containerName, responseErr.StatusCode)
+}
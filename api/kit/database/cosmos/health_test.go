@@ -0,0 +1,40 @@
+package cosmos
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+
+	"test-api/kit/database/databasetest"
+)
+
+func TestPingResult(t *testing.T) {
+	a := &Adapter[databasetest.Item]{dbName: "TestDB", containerName: "Users"}
+	responseErr := func(status int, subStatus string) error {
+		header := http.Header{}
+		header.Set("x-ms-substatus", subStatus)
+		return &azcore.ResponseError{StatusCode: status, RawResponse: &http.Response{Header: header}}
+	}
+
+	// Document sentinelle absent : le conteneur répond, c'est le cas nominal.
+	assert.NoError(t, a.pingResult(responseErr(http.StatusNotFound, "0")))
+	assert.NoError(t, a.pingResult(nil))
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"Conteneur inexistant", responseErr(http.StatusNotFound, "1003"), "cosmos: container Users not found"},
+		{"Identifiants refusés", responseErr(http.StatusForbidden, "5301"), "cosmos: container Users unavailable (status 403)"},
+		{"Réseau", errors.New("dial tcp: lookup erp.documents.azure.com: no such host"), "cosmos: container Users unreachable"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualError(t, a.pingResult(tc.err), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package cosmos
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+
+	"test-api/kit/database"
+)
+
+// compiledQuery est une requête SQL Cosmos paramétrée.
+type compiledQuery struct {
+	text   string
+	params []azcosmos.QueryParameter
+}
+
+// compileQuery traduit une database.Query en SQL Cosmos.
+// Les valeurs passent TOUJOURS par des paramètres (@p0, @p1...) ; les noms de champs sont validés
+// par Query.Validate avant d'être insérés dans le texte.
+func compileQuery[T database.Entity](q database.Query[T]) (compiledQuery, error) {
+	if err := q.Validate(); err != nil {
+		return compiledQuery{}, err
+	}
+
+	c := &queryCompiler{}
+	var sb strings.Builder
+
+	sb.WriteString("SELECT ")
+	if len(q.Fields) == 0 {
+		sb.WriteString("*")
+	} else {
+		for i, f := range q.Fields {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(fieldRef(f))
+		}
+	}
+	sb.WriteString(" FROM c")
+
+	if q.Filter != nil {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(c.condition(q.Filter))
+	}
+
+	if len(q.Sort) > 0 {
+		sb.WriteString(" ORDER BY ")
+		for i, s := range q.Sort {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(fieldRef(s.Field))
+			if s.Desc {
+				sb.WriteString(" DESC")
+			} else {
+				sb.WriteString(" ASC")
+			}
+		}
+	}
+
+	// Pas d'OFFSET/LIMIT : la taille de page est passée au SDK (PageSizeHint)
+	// et la position est portée par le jeton de continuation.
+
+	return compiledQuery{text: sb.String(), params: c.params}, nil
+}
+
+type queryCompiler struct {
+	params []azcosmos.QueryParameter
+}
+
+// param enregistre une valeur et retourne le nom du paramètre.
+func (c *queryCompiler) param(v any) string {
+	name := fmt.Sprintf("@p%d", len(c.params))
+	c.params = append(c.params, azcosmos.QueryParameter{Name: name, Value: v})
+	return name
+}
+
+func (c *queryCompiler) condition(cond database.Condition) string {
+	switch cond := cond.(type) {
+	case database.Group:
+		if len(cond.Conditions) == 0 {
+			// AND de rien = vrai, OR de rien = faux (élément neutre).
+			if cond.Logic == database.LogicOr {
+				return "false"
+			}
+			return "true"
+		}
+		parts := make([]string, len(cond.Conditions))
+		for i, sub := range cond.Conditions {
+			parts[i] = c.condition(sub)
+		}
+		return "(" + strings.Join(parts, " "+string(cond.Logic)+" ") + ")"
+
+	case database.Predicate:
+		f := fieldRef(cond.Field)
+		switch cond.Op {
+		case database.OpIn:
+			values := cond.Value.([]any)
+			if len(values) == 0 {
+				return "false"
+			}
+			names := make([]string, len(values))
+			for i, v := range values {
+				names[i] = c.param(v)
+			}
+			return f + " IN (" + strings.Join(names, ", ") + ")"
+		case database.OpContains, database.OpStartsWith:
+			return string(cond.Op) + "(" + f + ", " + c.param(cond.Value) + ")"
+		case database.OpIsNull:
+			return "(NOT IS_DEFINED(" + f + ") OR IS_NULL(" + f + "))"
+		case database.OpIsNotNull:
+			return "(IS_DEFINED(" + f + ") AND NOT IS_NULL(" + f + "))"
+		default:
+			return f + " " + string(cond.Op) + " " + c.param(cond.Value)
+		}
+	}
+	// Impossible après Validate.
+	return "false"
+}
+
+// fieldRef transforme "address.city" en c.address.city.
+func fieldRef(field string) string {
+	return "c." + field
+}
@@ -0,0 +1,77 @@
+package cosmos
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/apperr"
+	"test-api/kit/database"
+)
+
+type testEntity struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantID"`
+}
+
+func (e testEntity) GetID() string       { return e.ID }
+func (e testEntity) GetTenantID() string { return e.TenantID }
+
+func TestCompileQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    database.Query[testEntity]
+		expected string
+		params   []azcosmos.QueryParameter
+	}{
+		{
+			name:     "empty query",
+			query:    database.NewQuery[testEntity](),
+			expected: "SELECT * FROM c",
+		},
+		{
+			name: "filters and sort, pagination is left to the pager",
+			query: database.NewQuery[testEntity]().
+				Where(database.Eq("tenantID", "t1"), database.Gte("age", 18)).
+				OrderBy(database.Desc("_ts"), database.Asc("nom")).
+				Page(20, "token"),
+			expected: "SELECT * FROM c WHERE (c.tenantID = @p0 AND c.age >= @p1) ORDER BY c._ts DESC, c.nom ASC",
+			params: []azcosmos.QueryParameter{
+				{Name: "@p0", Value: "t1"}, {Name: "@p1", Value: 18},
+			},
+		},
+		{
+			name: "or group, in, text functions and null checks",
+			query: database.NewQuery[testEntity]().
+				Where(database.Or(
+					database.In("category", "a", "b"),
+					database.StartsWith("email", "arthur"),
+					database.Contains("nom", "drag"),
+				), database.IsNull("deletedAt")).
+				Select("id", "address.city"),
+			expected: "SELECT c.id, c.address.city FROM c WHERE ((c.category IN (@p0, @p1) OR STARTSWITH(c.email, @p2) OR CONTAINS(c.nom, @p3)) AND (NOT IS_DEFINED(c.deletedAt) OR IS_NULL(c.deletedAt)))",
+			params: []azcosmos.QueryParameter{
+				{Name: "@p0", Value: "a"}, {Name: "@p1", Value: "b"}, {Name: "@p2", Value: "arthur"}, {Name: "@p3", Value: "drag"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := compileQuery(tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, compiled.text)
+			assert.Equal(t, tc.params, compiled.params)
+		})
+	}
+}
+
+func TestCompileQuery_RejectsUnsafeFields(t *testing.T) {
+	q := database.NewQuery[testEntity]().Where(database.Eq("nom = 'x' OR 1=1 --", "y"))
+
+	_, err := compileQuery(q)
+
+	assert.ErrorIs(t, err, apperr.ErrInvalidInput)
+}
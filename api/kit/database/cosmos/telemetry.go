@@ -0,0 +1,158 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"test-api/kit/database"
+)
+
+const instrumentation = "test-api/kit/database/cosmos"
+
+// Bornes des histogrammes : latence en secondes, coût en RU.
+var (
+	durationBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	chargeBuckets   = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+)
+
+// tenantAttr porte le tenant (clé de partition) sur le total de RU consommées.
+const tenantAttr = attribute.Key("tenant.id")
+
+// notFoundAttr marque les spans terminés par un document absent (database.ErrNotFound).
+const notFoundAttr = attribute.Key("db.response.not_found")
+
+type instruments struct {
+	operations metric.Int64Counter
+	duration   metric.Float64Histogram
+	charge     metric.Float64Histogram
+	tenantRU   metric.Float64Counter
+}
+
+// meters crée les instruments au premier appel ; le fournisseur global (metrics.Init) les
+// prend en charge même s'il est installé après.
+var meters = sync.OnceValue(func() instruments {
+	meter := otel.Meter(instrumentation)
+	var i instruments
+	i.operations, _ = meter.Int64Counter("db.client.operations",
+		metric.WithDescription("Number of Cosmos DB operations."),
+		metric.WithUnit("{operation}"),
+	)
+	i.duration, _ = meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("Duration of Cosmos DB operations."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	i.charge, _ = meter.Float64Histogram("azure.cosmosdb.client.operation.request_charge",
+		metric.WithDescription("Request units consumed by Cosmos DB operations."),
+		metric.WithUnit("{request_unit}"),
+		metric.WithExplicitBucketBoundaries(chargeBuckets...),
+	)
+	i.tenantRU, _ = meter.Float64Counter("azure.cosmosdb.tenant.request_charge",
+		metric.WithDescription("Total request units consumed per tenant."),
+		metric.WithUnit("{request_unit}"),
+	)
+	return i
+})
+
+// operation est un appel Cosmos en cours : un span enfant de la requête HTTP, qui porte
+// l'opération, le conteneur, le coût en RU et le statut renvoyé par Cosmos, et les métriques
+// associées (nombre, latence et coût par conteneur/opération, RU cumulées par tenant).
+type operation struct {
+	ctx       context.Context
+	span      trace.Span
+	name      string
+	container string
+	tenantID  string
+	start     time.Time
+	charge    float32
+}
+
+// startOperation ouvre le span de l'appel (nommé "<opération> <conteneur>", ex : "ReadItem Users").
+// partitionKey est le tenant : tous les conteneurs sont partitionnés par tenantID.
+func (a *Adapter[T]) startOperation(ctx context.Context, name, partitionKey string) (context.Context, *operation) {
+	ctx, span := otel.Tracer(instrumentation).Start(ctx, name+" "+a.containerName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameAzureCosmosDB,
+			semconv.DBOperationName(name),
+			semconv.DBNamespace(a.dbName),
+			semconv.DBCollectionName(a.containerName),
+		),
+	)
+	return ctx, &operation{
+		ctx:       ctx,
+		span:      span,
+		name:      name,
+		container: a.containerName,
+		tenantID:  partitionKey,
+		start:     time.Now(),
+	}
+}
+
+// addCharge cumule le coût des pages d'une requête.
+func (o *operation) addCharge(charge float32) {
+	o.charge += charge
+}
+
+// end ferme le span avec le coût total et le statut Cosmos, et enregistre les métriques.
+// err est l'erreur brute du SDK : en cas d'échec, le coût et le sous-statut sont lus dans
+// les en-têtes de la réponse. Un document absent est une issue normale d'une lecture ou d'un
+// test d'existence : le span le signale par un attribut, sans passer en erreur.
+func (o *operation) end(err error) {
+	defer o.span.End()
+
+	status := "200"
+	if err != nil {
+		status = "error"
+	}
+	notFound := false
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		status = strconv.Itoa(responseErr.StatusCode)
+		subStatus := ""
+		if responseErr.RawResponse != nil {
+			header := responseErr.RawResponse.Header
+			if charge, perr := strconv.ParseFloat(header.Get("x-ms-request-charge"), 32); perr == nil {
+				o.charge += float32(charge)
+			}
+			subStatus = header.Get("x-ms-substatus")
+			if sub, perr := strconv.Atoi(subStatus); perr == nil {
+				o.span.SetAttributes(semconv.AzureCosmosDBResponseSubStatusCode(sub))
+			}
+		}
+		o.span.SetAttributes(semconv.DBResponseStatusCode(status))
+		// Conteneur ou base absents (sous-statut 1003) : c'est une erreur de configuration.
+		notFound = errors.Is(MapError(err), database.ErrNotFound) && subStatus != subStatusOwnerNotFound
+	}
+	o.span.SetAttributes(semconv.AzureCosmosDBOperationRequestCharge(float64(o.charge)))
+
+	switch {
+	case notFound:
+		o.span.SetAttributes(notFoundAttr.Bool(true))
+	case err != nil:
+		o.span.RecordError(err)
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+
+	m := meters()
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		semconv.DBCollectionName(o.container),
+		semconv.DBOperationName(o.name),
+		semconv.DBResponseStatusCode(status),
+	))
+	m.operations.Add(o.ctx, 1, attrs)
+	m.duration.Record(o.ctx, time.Since(o.start).Seconds(), attrs)
+	m.charge.Record(o.ctx, float64(o.charge), attrs)
+	m.tenantRU.Add(o.ctx, float64(o.charge), metric.WithAttributes(tenantAttr.String(o.tenantID), semconv.DBCollectionName(o.container)))
+}
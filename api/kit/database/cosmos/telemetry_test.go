@@ -0,0 +1,92 @@
+package cosmos
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"test-api/kit/database/databasetest"
+)
+
+func TestOperationTelemetry(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	previousTracer, previousMeter := otel.GetTracerProvider(), otel.GetMeterProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousTracer)
+		otel.SetMeterProvider(previousMeter)
+	})
+
+	a := &Adapter[databasetest.Item]{dbName: "TestDB", containerName: "Users"}
+
+	// 1. Requête sur deux pages : le coût est cumulé.
+	_, op := a.startOperation(context.Background(), "QueryItems", "tenant-A")
+	op.addCharge(2.5)
+	op.addCharge(3)
+	op.end(nil)
+
+	// 2. Échec (throttling) : le coût est lu dans les en-têtes de la réponse.
+	_, op = a.startOperation(context.Background(), "ReadItem", "tenant-A")
+	header := http.Header{}
+	header.Set("x-ms-request-charge", "1.5")
+	header.Set("x-ms-substatus", "3200")
+	op.end(&azcore.ResponseError{StatusCode: http.StatusTooManyRequests, RawResponse: &http.Response{Header: header}})
+
+	// 3. Document absent : issue normale, pas une erreur du span.
+	_, op = a.startOperation(context.Background(), "ReadItem", "tenant-A")
+	op.end(&azcore.ResponseError{StatusCode: http.StatusNotFound, RawResponse: &http.Response{Header: http.Header{}}})
+
+	// 4. Conteneur absent (sous-statut 1003) : erreur de configuration.
+	_, op = a.startOperation(context.Background(), "ReadItem", "tenant-A")
+	header = http.Header{}
+	header.Set("x-ms-substatus", "1003")
+	op.end(&azcore.ResponseError{StatusCode: http.StatusNotFound, RawResponse: &http.Response{Header: header}})
+
+	ended := spans.Ended()
+	require.Len(t, ended, 4)
+	assert.Equal(t, "QueryItems Users", ended[0].Name())
+	assert.Contains(t, ended[0].Attributes(), attribute.Float64("azure.cosmosdb.operation.request_charge", 5.5))
+	assert.Equal(t, "ReadItem Users", ended[1].Name())
+	assert.Contains(t, ended[1].Attributes(), attribute.String("db.response.status_code", "429"))
+	assert.Contains(t, ended[1].Attributes(), attribute.Int("azure.cosmosdb.response.sub_status_code", 3200))
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	assert.Contains(t, ended[2].Attributes(), attribute.String("db.response.status_code", "404"))
+	assert.Contains(t, ended[2].Attributes(), attribute.Bool("db.response.not_found", true))
+	assert.Equal(t, codes.Unset, ended[2].Status().Code)
+	assert.Empty(t, ended[2].Events(), "pas d'exception enregistrée")
+	assert.Equal(t, codes.Error, ended[3].Status().Code)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	byName := map[string]metricdata.Metrics{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+	tenantRU, ok := byName["azure.cosmosdb.tenant.request_charge"].Data.(metricdata.Sum[float64])
+	require.True(t, ok)
+	require.Len(t, tenantRU.DataPoints, 1)
+	assert.Equal(t, 7.0, tenantRU.DataPoints[0].Value)
+	tenant, _ := tenantRU.DataPoints[0].Attributes.Value("tenant.id")
+	assert.Equal(t, "tenant-A", tenant.AsString())
+
+	operations, ok := byName["db.client.operations"].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	assert.Len(t, operations.DataPoints, 3, "une série par opération et statut")
+	assert.Contains(t, byName, "db.client.operation.duration")
+	assert.Contains(t, byName, "azure.cosmosdb.client.operation.request_charge")
+}
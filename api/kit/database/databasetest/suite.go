@@ -0,0 +1,317 @@
+// Package databasetest fournit une suite de conformité pour les implémentations de database.Repository.
+//
+// Tout adaptateur (Cosmos, mémoire, futur Postgres...) doit passer la même suite : c'est elle qui
+// garantit que les tests de domaine écrits contre l'adaptateur en mémoire restent valables en production.
+//
+//	func TestConformance(t *testing.T) {
+//		databasetest.RunRepositorySuite(t, func(t *testing.T) database.Repository[databasetest.Item] {
+//			return memory.NewAdapter[databasetest.Item]()
+//		})
+//	}
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/database"
+)
+
+// Item est l'entité utilisée par la suite. Le conteneur sous-jacent doit être partitionné sur /tenantID.
+type Item struct {
+	ID       string  `json:"id"`
+	TenantID string  `json:"tenantID"`
+	Name     string  `json:"name"`
+	Category string  `json:"category,omitempty"`
+	Rank     int     `json:"rank"`
+	Note     *string `json:"note,omitempty"`
+	ETag     string  `json:"_etag,omitempty"`
+}
+
+func (i Item) GetID() string        { return i.ID }
+func (i Item) GetTenantID() string  { return i.TenantID }
+func (i Item) GetETag() string      { return i.ETag }
+func (i *Item) SetETag(etag string) { i.ETag = etag }
+
+// Factory fournit le repository à tester. Elle peut renvoyer la même instance à chaque appel :
+// chaque test travaille dans ses propres partitions (tenants aléatoires).
+type Factory func(t *testing.T) database.Repository[Item]
+
+// RunRepositorySuite exécute tous les scénarios de conformité.
+func RunRepositorySuite(t *testing.T, factory Factory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo database.Repository[Item])
+	}{
+		{"CreateAndRead", testCreateAndRead},
+		{"ReadMissing", testReadMissing},
+		{"CreateDuplicate", testCreateDuplicate},
+		{"UpdateAndDelete", testUpdateAndDelete},
+		{"UpdateMissing", testUpdateMissing},
+		{"StaleETag", testStaleETag},
+		{"TenantIsolation", testTenantIsolation},
+		{"Filtering", testFiltering},
+		{"Ordering", testOrdering},
+		{"Pagination", testPagination},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, factory(t))
+		})
+	}
+}
+
+// newTenant renvoie une partition propre au test, pour pouvoir partager un conteneur réel.
+func newTenant() string {
+	return "tenant-" + uuid.NewString()
+}
+
+func newItem(tenantID, name string) Item {
+	return Item{ID: uuid.NewString(), TenantID: tenantID, Name: name}
+}
+
+func mustCreate(t *testing.T, repo database.Repository[Item], item Item) Item {
+	t.Helper()
+	created, err := repo.Create(context.Background(), item)
+	require.NoError(t, err)
+	return created
+}
+
+func testCreateAndRead(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	note := "fragile"
+	item := newItem(newTenant(), "Excalibur")
+	item.Category, item.Rank, item.Note = "arme", 1, &note
+
+	created := mustCreate(t, repo, item)
+	assert.NotEmpty(t, created.ETag, "Create doit renseigner l'ETag")
+
+	read, err := repo.Read(ctx, item.ID, item.TenantID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ETag, read.ETag)
+
+	read.ETag = ""
+	assert.Equal(t, item, read)
+}
+
+func testReadMissing(t *testing.T, repo database.Repository[Item]) {
+	_, err := repo.Read(context.Background(), uuid.NewString(), newTenant())
+	assert.ErrorIs(t, err, database.ErrNotFound)
+}
+
+func testCreateDuplicate(t *testing.T, repo database.Repository[Item]) {
+	item := newItem(newTenant(), "Graal")
+	mustCreate(t, repo, item)
+
+	_, err := repo.Create(context.Background(), item)
+	assert.ErrorIs(t, err, database.ErrConflict)
+}
+
+func testUpdateAndDelete(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, newItem(newTenant(), "Table ronde"))
+
+	created.Name = "Table carrée"
+	updated, err := repo.Update(ctx, created)
+	require.NoError(t, err)
+	assert.NotEqual(t, created.ETag, updated.ETag, "chaque écriture produit un nouvel ETag")
+
+	read, err := repo.Read(ctx, created.ID, created.TenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "Table carrée", read.Name)
+
+	require.NoError(t, repo.Delete(ctx, created.ID, created.TenantID, database.IfMatch(updated.ETag)))
+
+	_, err = repo.Read(ctx, created.ID, created.TenantID)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, created.ID, created.TenantID), database.ErrNotFound)
+}
+
+func testUpdateMissing(t *testing.T, repo database.Repository[Item]) {
+	_, err := repo.Update(context.Background(), newItem(newTenant(), "Fantôme"))
+	assert.ErrorIs(t, err, database.ErrNotFound)
+}
+
+func testStaleETag(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, newItem(newTenant(), "Kaamelott"))
+
+	first := created
+	first.Rank = 1
+	_, err := repo.Update(ctx, first)
+	require.NoError(t, err)
+
+	// "created" porte maintenant un ETag périmé.
+	stale := created
+	stale.Rank = 2
+	_, err = repo.Update(ctx, stale)
+	assert.ErrorIs(t, err, database.ErrPreconditionFailed)
+
+	assert.ErrorIs(t, repo.Delete(ctx, created.ID, created.TenantID, database.IfMatch(created.ETag)), database.ErrPreconditionFailed)
+
+	// Sans précondition explicite, l'option IfMatch("") force une écriture inconditionnelle.
+	_, err = repo.Update(ctx, stale, database.IfMatch(""))
+	assert.NoError(t, err)
+}
+
+func testTenantIsolation(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	tenantA, tenantB := newTenant(), newTenant()
+
+	item := newItem(tenantA, "Arthur")
+	mustCreate(t, repo, item)
+
+	// Même ID dans un autre tenant : ce sont deux documents distincts.
+	twin := item
+	twin.TenantID, twin.Name = tenantB, "Usurpateur"
+	mustCreate(t, repo, twin)
+
+	read, err := repo.Read(ctx, item.ID, tenantA)
+	require.NoError(t, err)
+	assert.Equal(t, "Arthur", read.Name)
+
+	_, err = repo.Read(ctx, uuid.NewString(), tenantB)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	page, err := repo.Search(ctx, database.NewQuery[Item](), tenantB)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Usurpateur", page.Items[0].Name)
+
+	require.NoError(t, repo.Delete(ctx, item.ID, tenantB))
+	_, err = repo.Read(ctx, item.ID, tenantA)
+	assert.NoError(t, err, "supprimer dans le tenant B ne touche pas le tenant A")
+}
+
+func testFiltering(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	tenant := newTenant()
+	note := "x"
+	seed := []Item{
+		{Name: "Arthur", Category: "roi", Rank: 1},
+		{Name: "Lancelot", Category: "chevalier", Rank: 2, Note: &note},
+		{Name: "Perceval", Category: "chevalier", Rank: 3},
+		{Name: "Karadoc", Category: "chevalier", Rank: 4},
+		{Name: "Merlin", Rank: 5},
+	}
+	for _, it := range seed {
+		it.ID, it.TenantID = uuid.NewString(), tenant
+		mustCreate(t, repo, it)
+	}
+
+	names := func(conds ...database.Condition) []string {
+		t.Helper()
+		page, err := repo.Search(ctx, database.NewQuery[Item]().Where(conds...), tenant)
+		require.NoError(t, err)
+		var out []string
+		for _, it := range page.Items {
+			out = append(out, it.Name)
+		}
+		return out
+	}
+
+	assert.ElementsMatch(t, []string{"Lancelot", "Perceval", "Karadoc"}, names(database.Eq("category", "chevalier")))
+	assert.ElementsMatch(t, []string{"Perceval", "Karadoc"}, names(database.Gte("rank", 3), database.Lt("rank", 5)))
+	assert.ElementsMatch(t, []string{"Arthur", "Merlin"}, names(database.In("name", "Arthur", "Merlin", "Inconnu")))
+	assert.ElementsMatch(t, []string{"Lancelot"}, names(database.Contains("name", "cel")))
+	assert.ElementsMatch(t, []string{"Perceval"}, names(database.StartsWith("name", "Per")))
+	assert.ElementsMatch(t, []string{"Lancelot"}, names(database.IsNotNull("note")))
+	assert.ElementsMatch(t, []string{"Merlin"}, names(database.IsNull("category")))
+	assert.ElementsMatch(t, []string{"Arthur", "Karadoc"}, names(database.Or(database.Eq("category", "roi"), database.Eq("rank", 4))))
+	assert.ElementsMatch(t, []string{"Arthur", "Perceval", "Karadoc", "Merlin"}, names(database.Ne("name", "Lancelot")))
+}
+
+func testOrdering(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	tenant := newTenant()
+	for _, rank := range []int{3, 1, 2} {
+		it := newItem(tenant, fmt.Sprintf("item-%d", rank))
+		it.Rank = rank
+		mustCreate(t, repo, it)
+	}
+
+	ranks := func(sort database.SortField) []int {
+		t.Helper()
+		page, err := repo.Search(ctx, database.NewQuery[Item]().OrderBy(sort), tenant)
+		require.NoError(t, err)
+		var out []int
+		for _, it := range page.Items {
+			out = append(out, it.Rank)
+		}
+		return out
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, ranks(database.Asc("rank")))
+	assert.Equal(t, []int{3, 2, 1}, ranks(database.Desc("rank")))
+}
+
+func testPagination(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	tenant := newTenant()
+	const total = 7
+	for i := 0; i < total; i++ {
+		it := newItem(tenant, fmt.Sprintf("item-%d", i))
+		it.Rank = i
+		mustCreate(t, repo, it)
+	}
+
+	var ranks []int
+	continuation := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, total+1, "la pagination doit se terminer")
+
+		q := database.NewQuery[Item]().OrderBy(database.Asc("rank")).Page(3, continuation)
+		page, err := repo.Search(ctx, q, tenant)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Items), 3)
+
+		for _, it := range page.Items {
+			ranks = append(ranks, it.Rank)
+		}
+		if page.Continuation == "" {
+			break
+		}
+		continuation = page.Continuation
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, ranks, "chaque élément apparaît une fois, dans l'ordre")
+}
+
+func testConcurrentUpdates(t *testing.T, repo database.Repository[Item]) {
+	ctx := context.Background()
+	created := mustCreate(t, repo, newItem(newTenant(), "Disputé"))
+
+	const writers = 5
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			it := created
+			it.Rank = i + 1
+			_, errs[i] = repo.Update(ctx, it)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, database.ErrPreconditionFailed)
+	}
+	assert.Equal(t, 1, succeeded, "avec le même ETag, une seule écriture concurrente doit gagner")
+}
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"fmt"
+
+	"test-api/kit/apperr"
+)
+
+// Erreurs standard de la couche de persistance, indépendantes du moteur (Cosmos, mémoire...).
+// Chaque adaptateur traduit ses erreurs natives vers ces sentinelles en conservant l'erreur
+// d'origine dans la chaîne : errors.Is(err, database.ErrNotFound) suffit côté domaine.
+//
+// Elles enveloppent une catégorie de kit/apperr pour que l'API réponde avec le bon statut
+// si un domaine les laisse remonter telles quelles.
+var (
+	ErrNotFound           = fmt.Errorf("database: item not found: %w", apperr.ErrNotFound)
+	ErrConflict           = fmt.Errorf("database: item already exists: %w", apperr.ErrConflict)
+	ErrPreconditionFailed = fmt.Errorf("database: precondition failed: %w", apperr.ErrPreconditionFailed)
+	ErrThrottled          = fmt.Errorf("database: request throttled: %w", apperr.ErrRateLimited)
+
+	// ErrUnauthorized signale un problème d'identité ou de droits du SERVEUR vis-à-vis de la base.
+	// Ce n'est pas la faute du client : on le classe en erreur interne (500).
+	ErrUnauthorized = fmt.Errorf("database: access denied: %w", apperr.ErrInternal)
+)
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"test-api/kit/database"
+	"test-api/kit/database/databasetest"
+)
+
+func TestConformance(t *testing.T) {
+	databasetest.RunRepositorySuite(t, func(t *testing.T) database.Repository[databasetest.Item] {
+		return NewAdapter[databasetest.Item]()
+	})
+}
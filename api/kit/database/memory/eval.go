@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"encoding/json"
+	"strings"
+
+	"test-api/kit/database"
+)
+
+// undefined représente un champ absent (IS_DEFINED = false en SQL Cosmos).
+type undefined struct{}
+
+// normalizeCondition fait passer les valeurs des prédicats par JSON pour qu'elles aient
+// les mêmes types que les documents stockés (nombres en float64, dates en string...).
+func normalizeCondition(c database.Condition) (database.Condition, error) {
+	switch c := c.(type) {
+	case database.Group:
+		conds := make([]database.Condition, len(c.Conditions))
+		for i, sub := range c.Conditions {
+			n, err := normalizeCondition(sub)
+			if err != nil {
+				return nil, err
+			}
+			conds[i] = n
+		}
+		return database.Group{Logic: c.Logic, Conditions: conds}, nil
+	case database.Predicate:
+		v, err := normalizeValue(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		return database.Predicate{Field: c.Field, Op: c.Op, Value: v}, nil
+	}
+	return c, nil
+}
+
+func normalizeValue(v any) (any, error) {
+	if values, ok := v.([]any); ok {
+		out := make([]any, len(values))
+		for i, x := range values {
+			n, err := normalizeValue(x)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = n
+		}
+		return out, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+// evaluate applique une condition (déjà validée et normalisée) à un document.
+func evaluate(c database.Condition, fields map[string]any) bool {
+	switch c := c.(type) {
+	case nil:
+		return true
+	case database.Group:
+		if c.Logic == database.LogicOr {
+			for _, sub := range c.Conditions {
+				if evaluate(sub, fields) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, sub := range c.Conditions {
+			if !evaluate(sub, fields) {
+				return false
+			}
+		}
+		return true
+	case database.Predicate:
+		return evaluatePredicate(c, lookup(fields, c.Field))
+	}
+	return false
+}
+
+func evaluatePredicate(p database.Predicate, v any) bool {
+	switch p.Op {
+	case database.OpIsNull:
+		return v == nil || v == (undefined{})
+	case database.OpIsNotNull:
+		return v != nil && v != (undefined{})
+	case database.OpIn:
+		for _, candidate := range p.Value.([]any) {
+			if c, ok := compare(v, candidate); ok && c == 0 {
+				return true
+			}
+		}
+		return false
+	case database.OpContains, database.OpStartsWith:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		if p.Op == database.OpContains {
+			return strings.Contains(s, p.Value.(string))
+		}
+		return strings.HasPrefix(s, p.Value.(string))
+	}
+
+	// Comme en SQL Cosmos, comparer des types différents (ou un champ absent) donne "undefined" : faux.
+	c, ok := compare(v, p.Value)
+	if !ok {
+		return false
+	}
+	switch p.Op {
+	case database.OpEq:
+		return c == 0
+	case database.OpNe:
+		return c != 0
+	case database.OpLt:
+		return c < 0
+	case database.OpLte:
+		return c <= 0
+	case database.OpGt:
+		return c > 0
+	case database.OpGte:
+		return c >= 0
+	}
+	return false
+}
+
+// compare compare deux valeurs JSON de même type. ok = false si les types diffèrent.
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case nil:
+		return 0, b == nil
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	return 0, false
+}
+
+// compareForSort ordonne des valeurs de types quelconques comme Cosmos :
+// undefined < null < booléens < nombres < chaînes < le reste.
+func compareForSort(a, b any) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	c, _ := compare(a, b)
+	return c
+}
+
+func typeRank(v any) int {
+	switch v.(type) {
+	case undefined:
+		return 0
+	case nil:
+		return 1
+	case bool:
+		return 2
+	case float64:
+		return 3
+	case string:
+		return 4
+	}
+	return 5
+}
+
+// lookup suit un chemin "a.b.c" ; renvoie undefined{} si un segment est absent.
+func lookup(fields map[string]any, path string) any {
+	v, ok := lookupDefined(fields, path)
+	if !ok {
+		return undefined{}
+	}
+	return v
+}
+
+func lookupDefined(fields map[string]any, path string) (any, bool) {
+	var current any = fields
+	for _, segment := range strings.Split(path, ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func lastSegment(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
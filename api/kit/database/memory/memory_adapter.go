@@ -0,0 +1,299 @@
+// Package memory fournit une implémentation en mémoire de database.Repository.
+//
+// Elle reproduit la sémantique de l'adaptateur Cosmos (partitions, requêtes génériques, ETags,
+// sentinelles d'erreur, jetons de continuation, expiration par champ "ttl") pour les tests de domaine et le lancement
+// local sans Azure. Les documents sont stockés sous forme JSON, comme dans Cosmos :
+// les filtres portent donc sur les noms de champs JSON.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"test-api/kit/apperr"
+	"test-api/kit/database"
+)
+
+// document est un élément stocké, avec les propriétés système gérées par l'adaptateur.
+type document struct {
+	fields map[string]any
+	etag   string
+	// seq départage les documents de même _ts pour un ordre stable.
+	seq uint64
+}
+
+// Adapter implémente database.Repository en mémoire. Il est sûr en accès concurrent.
+type Adapter[T database.Entity] struct {
+	mu sync.RWMutex
+	// partitions[partitionKey][id]
+	partitions map[string]map[string]*document
+	seq        uint64
+
+	// now est surchargeable dans les tests.
+	now func() time.Time
+}
+
+// NewAdapter crée un adaptateur vide.
+func NewAdapter[T database.Entity]() *Adapter[T] {
+	return &Adapter[T]{
+		partitions: make(map[string]map[string]*document),
+		now:        time.Now,
+	}
+}
+
+func (a *Adapter[T]) Create(ctx context.Context, item T) (T, error) {
+	if err := ctx.Err(); err != nil {
+		return item, err
+	}
+	if item.GetID() == "" {
+		return item, apperr.New(apperr.ErrInvalidInput, "item id is required")
+	}
+
+	fields, err := toFields(item)
+	if err != nil {
+		return item, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	partition := a.partition(item.GetTenantID())
+	if _, exists := a.lookupLive(partition, item.GetID()); exists {
+		return item, fmt.Errorf("create %q: %w", item.GetID(), database.ErrConflict)
+	}
+
+	doc := a.store(partition, item.GetID(), fields)
+	return fromDocument[T](doc)
+}
+
+func (a *Adapter[T]) Read(ctx context.Context, id string, partitionKey string) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	doc, ok := a.lookupLive(a.partitions[partitionKey], id)
+	if !ok {
+		return zero, fmt.Errorf("read %q: %w", id, database.ErrNotFound)
+	}
+	return fromDocument[T](doc)
+}
+
+func (a *Adapter[T]) Update(ctx context.Context, item T, opts ...database.WriteOption) (T, error) {
+	if err := ctx.Err(); err != nil {
+		return item, err
+	}
+
+	options := database.ApplyWriteOptions(&item, opts)
+	fields, err := toFields(item)
+	if err != nil {
+		return item, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	partition := a.partitions[item.GetTenantID()]
+	existing, ok := a.lookupLive(partition, item.GetID())
+	if !ok {
+		return item, fmt.Errorf("update %q: %w", item.GetID(), database.ErrNotFound)
+	}
+	if options.IfMatch != "" && options.IfMatch != existing.etag {
+		return item, fmt.Errorf("update %q: %w", item.GetID(), database.ErrPreconditionFailed)
+	}
+
+	doc := a.store(partition, item.GetID(), fields)
+	return fromDocument[T](doc)
+}
+
+func (a *Adapter[T]) Delete(ctx context.Context, id string, partitionKey string, opts ...database.WriteOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	options := database.ApplyWriteOptions[T](nil, opts)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	existing, ok := a.lookupLive(a.partitions[partitionKey], id)
+	if !ok {
+		return fmt.Errorf("delete %q: %w", id, database.ErrNotFound)
+	}
+	if options.IfMatch != "" && options.IfMatch != existing.etag {
+		return fmt.Errorf("delete %q: %w", id, database.ErrPreconditionFailed)
+	}
+
+	delete(a.partitions[partitionKey], id)
+	return nil
+}
+
+// Search évalue la requête sur les documents de la partition.
+// Le jeton de continuation est la position dans le résultat trié.
+func (a *Adapter[T]) Search(ctx context.Context, query database.Query[T], partitionKey string) (database.Page[T], error) {
+	if err := ctx.Err(); err != nil {
+		return database.Page[T]{}, err
+	}
+	if err := query.Validate(); err != nil {
+		return database.Page[T]{}, err
+	}
+
+	start := 0
+	if query.Continuation != "" {
+		var err error
+		start, err = strconv.Atoi(query.Continuation)
+		if err != nil || start < 0 {
+			return database.Page[T]{}, apperr.New(apperr.ErrInvalidInput, "invalid continuation token")
+		}
+	}
+
+	filter, err := normalizeCondition(query.Filter)
+	if err != nil {
+		return database.Page[T]{}, err
+	}
+
+	a.mu.RLock()
+	var matches []*document
+	now := a.now()
+	for _, doc := range a.partitions[partitionKey] {
+		if !doc.expired(now) && evaluate(filter, doc.fields) {
+			matches = append(matches, doc)
+		}
+	}
+	a.mu.RUnlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, s := range query.Sort {
+			c := compareForSort(lookup(matches[i].fields, s.Field), lookup(matches[j].fields, s.Field))
+			if c == 0 {
+				continue
+			}
+			if s.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return matches[i].seq < matches[j].seq
+	})
+
+	if start > len(matches) {
+		start = len(matches)
+	}
+	matches = matches[start:]
+
+	var page database.Page[T]
+	if query.Limit > 0 && len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+		page.Continuation = strconv.Itoa(start + query.Limit)
+	}
+
+	for _, doc := range matches {
+		var item T
+		var err error
+		if len(query.Fields) > 0 {
+			item, err = project[T](doc, query.Fields)
+		} else {
+			item, err = fromDocument[T](doc)
+		}
+		if err != nil {
+			return database.Page[T]{}, err
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	return page, nil
+}
+
+// =================================================================================
+// Helpers de stockage
+// =================================================================================
+
+func (a *Adapter[T]) partition(key string) map[string]*document {
+	p, ok := a.partitions[key]
+	if !ok {
+		p = make(map[string]*document)
+		a.partitions[key] = p
+	}
+	return p
+}
+
+// lookupLive retourne le document s'il existe et n'a pas expiré.
+// Comme dans Cosmos, un document expiré est invisible même s'il n'est pas encore physiquement supprimé.
+func (a *Adapter[T]) lookupLive(partition map[string]*document, id string) (*document, bool) {
+	doc, ok := partition[id]
+	if !ok || doc.expired(a.now()) {
+		return nil, false
+	}
+	return doc, true
+}
+
+// expired applique la sémantique TTL Cosmos : un champ "ttl" (secondes) > 0 expire le document
+// ttl secondes après sa dernière écriture (_ts).
+func (d *document) expired(now time.Time) bool {
+	ttl, ok := d.fields["ttl"].(float64)
+	if !ok || ttl <= 0 {
+		return false
+	}
+	ts, _ := d.fields["_ts"].(float64)
+	return float64(now.Unix()) >= ts+ttl
+}
+
+// store écrit le document avec de nouvelles propriétés système (_etag, _ts), comme Cosmos.
+// Doit être appelé verrou pris.
+func (a *Adapter[T]) store(partition map[string]*document, id string, fields map[string]any) *document {
+	a.seq++
+	etag := `"` + uuid.NewString() + `"`
+	fields["_etag"] = etag
+	fields["_ts"] = float64(a.now().Unix())
+
+	doc := &document{fields: fields, etag: etag, seq: a.seq}
+	partition[id] = doc
+	return doc
+}
+
+// toFields convertit une entité en document JSON générique (copie profonde).
+func toFields(item any) (map[string]any, error) {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func fromDocument[T database.Entity](doc *document) (T, error) {
+	var item T
+	b, err := json.Marshal(doc.fields)
+	if err != nil {
+		return item, err
+	}
+	if err := json.Unmarshal(b, &item); err != nil {
+		return item, fmt.Errorf("erreur de désérialisation: %w", err)
+	}
+	database.SetETag(&item, doc.etag)
+	return item, nil
+}
+
+// project reproduit "SELECT c.a, c.b.c FROM c" : chaque champ est renvoyé sous son dernier segment.
+func project[T database.Entity](doc *document, paths []string) (T, error) {
+	fields := make(map[string]any, len(paths))
+	for _, p := range paths {
+		if v, ok := lookupDefined(doc.fields, p); ok {
+			fields[lastSegment(p)] = v
+		}
+	}
+	return fromDocument[T](&document{fields: fields})
+}
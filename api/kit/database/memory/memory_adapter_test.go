@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/database"
+)
+
+type article struct {
+	ID       string   `json:"id"`
+	TenantID string   `json:"tenantID"`
+	Name     string   `json:"name"`
+	Price    float64  `json:"price"`
+	Category *string  `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	ETag     string   `json:"_etag,omitempty"`
+}
+
+func (a article) GetID() string        { return a.ID }
+func (a article) GetTenantID() string  { return a.TenantID }
+func (a article) GetETag() string      { return a.ETag }
+func (a *article) SetETag(etag string) { a.ETag = etag }
+
+func TestAdapter_SearchEvaluation(t *testing.T) {
+	ctx := context.Background()
+	a := NewAdapter[article]()
+	food := "food"
+	for _, item := range []article{
+		{ID: "1", TenantID: "t1", Name: "Pomme", Price: 1.5, Category: &food},
+		{ID: "2", TenantID: "t1", Name: "Poire", Price: 2},
+		{ID: "3", TenantID: "t1", Name: "Ananas", Price: 4, Category: &food},
+		{ID: "4", TenantID: "t2", Name: "Pomme", Price: 1},
+	} {
+		_, err := a.Create(ctx, item)
+		require.NoError(t, err)
+	}
+
+	ids := func(q database.Query[article]) []string {
+		page, err := a.Search(ctx, q, "t1")
+		require.NoError(t, err)
+		var out []string
+		for _, it := range page.Items {
+			out = append(out, it.ID)
+		}
+		return out
+	}
+
+	q := database.NewQuery[article]()
+	assert.Equal(t, []string{"1", "3"}, ids(q.Where(database.Eq("category", "food")).OrderBy(database.Asc("price"))))
+	assert.Equal(t, []string{"2"}, ids(q.Where(database.IsNull("category"))))
+	assert.Equal(t, []string{"1", "2"}, ids(q.Where(database.StartsWith("name", "Po")).OrderBy(database.Asc("id"))))
+	assert.Equal(t, []string{"3", "2"}, ids(q.Where(database.Or(database.Gte("price", 3), database.In("name", "Poire"))).OrderBy(database.Desc("price"))))
+	// Une comparaison entre types différents est fausse, comme en SQL Cosmos.
+	assert.Empty(t, ids(q.Where(database.Eq("price", "2"))))
+
+	// Projection : seuls les champs demandés sont renvoyés.
+	page, err := a.Search(ctx, q.Where(database.Eq("id", "3")).Select("id", "name"), "t1")
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, article{ID: "3", Name: "Ananas"}, page.Items[0])
+}
+
+func TestAdapter_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	a := NewAdapter[expiring]()
+	a.now = func() time.Time { return now }
+
+	_, err := a.Create(ctx, expiring{ID: "1", TenantID: "t1", TTL: 60})
+	require.NoError(t, err)
+	_, err = a.Create(ctx, expiring{ID: "2", TenantID: "t1"})
+	require.NoError(t, err)
+
+	_, err = a.Read(ctx, "1", "t1")
+	require.NoError(t, err, "pas encore expiré")
+
+	now = now.Add(61 * time.Second)
+
+	_, err = a.Read(ctx, "1", "t1")
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	page, err := a.Search(ctx, database.NewQuery[expiring](), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, []expiring{{ID: "2", TenantID: "t1"}}, page.Items)
+
+	// L'ID d'un document expiré est de nouveau disponible.
+	_, err = a.Create(ctx, expiring{ID: "1", TenantID: "t1"})
+	assert.NoError(t, err)
+}
+
+type expiring struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantID"`
+	TTL      int    `json:"ttl,omitempty"`
+}
+
+func (e expiring) GetID() string       { return e.ID }
+func (e expiring) GetTenantID() string { return e.TenantID }
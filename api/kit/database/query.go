@@ -0,0 +1,229 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+
+	"test-api/kit/apperr"
+)
+
+// =================================================================================
+// Spécification de requête générique
+// =================================================================================
+// Une Query décrit QUOI chercher (filtres, tri, projection, pagination) sans dire COMMENT :
+// chaque adaptateur la compile vers son langage (SQL Cosmos paramétré, évaluation en mémoire...).
+// Les noms de champs sont les noms JSON des entités ("email", "address.city").
+//
+// Exemple :
+//
+//	q := database.NewQuery[User]().
+//		Where(database.Eq("nom", "Pendragon"), database.StartsWith("email", "arthur")).
+//		OrderBy(database.Desc("_ts")).
+//		Page(20, "")
+
+// Operator est un opérateur de comparaison.
+type Operator string
+
+const (
+	OpEq         Operator = "="
+	OpNe         Operator = "!="
+	OpLt         Operator = "<"
+	OpLte        Operator = "<="
+	OpGt         Operator = ">"
+	OpGte        Operator = ">="
+	OpIn         Operator = "IN"
+	OpContains   Operator = "CONTAINS"
+	OpStartsWith Operator = "STARTSWITH"
+	OpIsNull     Operator = "IS_NULL"
+	OpIsNotNull  Operator = "IS_NOT_NULL"
+)
+
+// Logic est l'opérateur logique d'un groupe de conditions.
+type Logic string
+
+const (
+	LogicAnd Logic = "AND"
+	LogicOr  Logic = "OR"
+)
+
+// Condition est soit un Predicate, soit un Group.
+type Condition interface {
+	isCondition()
+}
+
+// Predicate compare un champ à une valeur.
+// Pour OpIn, Value est un []any ; pour OpIsNull / OpIsNotNull, Value est ignorée.
+type Predicate struct {
+	Field string
+	Op    Operator
+	Value any
+}
+
+// Group combine plusieurs conditions avec AND ou OR.
+type Group struct {
+	Logic      Logic
+	Conditions []Condition
+}
+
+func (Predicate) isCondition() {}
+func (Group) isCondition()     {}
+
+// Constructeurs de conditions.
+
+func Eq(field string, value any) Condition  { return Predicate{field, OpEq, value} }
+func Ne(field string, value any) Condition  { return Predicate{field, OpNe, value} }
+func Lt(field string, value any) Condition  { return Predicate{field, OpLt, value} }
+func Lte(field string, value any) Condition { return Predicate{field, OpLte, value} }
+func Gt(field string, value any) Condition  { return Predicate{field, OpGt, value} }
+func Gte(field string, value any) Condition { return Predicate{field, OpGte, value} }
+
+// In teste l'appartenance du champ à une liste de valeurs.
+func In(field string, values ...any) Condition { return Predicate{field, OpIn, values} }
+
+// Contains et StartsWith s'appliquent aux champs texte (sensibles à la casse).
+func Contains(field string, substr string) Condition   { return Predicate{field, OpContains, substr} }
+func StartsWith(field string, prefix string) Condition { return Predicate{field, OpStartsWith, prefix} }
+
+// IsNull est vrai si le champ est absent ou null ; IsNotNull est son contraire.
+func IsNull(field string) Condition    { return Predicate{field, OpIsNull, nil} }
+func IsNotNull(field string) Condition { return Predicate{field, OpIsNotNull, nil} }
+
+func And(conds ...Condition) Condition { return Group{LogicAnd, conds} }
+func Or(conds ...Condition) Condition  { return Group{LogicOr, conds} }
+
+// SortField est un critère de tri.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+func Asc(field string) SortField  { return SortField{Field: field} }
+func Desc(field string) SortField { return SortField{Field: field, Desc: true} }
+
+// Query est la spécification d'une recherche sur des entités T.
+// Le paramètre de type garantit qu'une Query[Product] ne peut pas être passée à un Repository[User].
+type Query[T Entity] struct {
+	// Filter est nil pour "tout".
+	Filter Condition
+	Sort   []SortField
+	// Fields limite les champs retournés (projection). Vide = document complet.
+	Fields []string
+
+	// Pagination par curseur (keyset) : Limit est la taille de page (0 = tout ramener),
+	// Continuation est le jeton renvoyé par la page précédente ("" = première page).
+	// Pas d'OFFSET : son coût croît avec la position dans la liste.
+	Limit        int
+	Continuation string
+}
+
+// Page est une page de résultats.
+type Page[T Entity] struct {
+	Items []T
+	// Continuation permet de demander la page suivante ("" = dernière page).
+	// C'est un jeton brut du moteur : il ne doit pas être exposé tel quel au client (voir kit/pagination).
+	Continuation string
+}
+
+// NewQuery crée une requête vide (tous les éléments de la partition).
+func NewQuery[T Entity]() Query[T] {
+	return Query[T]{}
+}
+
+// Where ajoute des conditions, combinées en AND avec les conditions existantes.
+func (q Query[T]) Where(conds ...Condition) Query[T] {
+	if q.Filter != nil {
+		conds = append([]Condition{q.Filter}, conds...)
+	}
+	if len(conds) == 1 {
+		q.Filter = conds[0]
+	} else {
+		q.Filter = And(conds...)
+	}
+	return q
+}
+
+// OrderBy ajoute des critères de tri.
+func (q Query[T]) OrderBy(fields ...SortField) Query[T] {
+	q.Sort = append(append([]SortField(nil), q.Sort...), fields...)
+	return q
+}
+
+// Select restreint les champs retournés.
+func (q Query[T]) Select(fields ...string) Query[T] {
+	q.Fields = append(append([]string(nil), q.Fields...), fields...)
+	return q
+}
+
+// Page définit la taille de page et le jeton de continuation de la page précédente.
+func (q Query[T]) Page(limit int, continuation string) Query[T] {
+	q.Limit = limit
+	q.Continuation = continuation
+	return q
+}
+
+// fieldPattern accepte "email", "_ts", "address.city". Les noms de champs sont insérés tels quels
+// dans la requête compilée (seules les valeurs sont paramétrées) : ce contrôle empêche l'injection.
+var fieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// ValidateField vérifie qu'un nom de champ est sûr.
+func ValidateField(field string) error {
+	if !fieldPattern.MatchString(field) {
+		return apperr.Wrap(apperr.ErrInvalidInput, "invalid query field", fmt.Errorf("field %q", field))
+	}
+	return nil
+}
+
+// Validate vérifie la cohérence de la requête (noms de champs, opérateurs, pagination).
+func (q Query[T]) Validate() error {
+	if q.Limit < 0 {
+		return apperr.New(apperr.ErrInvalidInput, "invalid pagination")
+	}
+	for _, f := range q.Fields {
+		if err := ValidateField(f); err != nil {
+			return err
+		}
+	}
+	for _, s := range q.Sort {
+		if err := ValidateField(s.Field); err != nil {
+			return err
+		}
+	}
+	return validateCondition(q.Filter)
+}
+
+func validateCondition(c Condition) error {
+	switch c := c.(type) {
+	case nil:
+		return nil
+	case Group:
+		if c.Logic != LogicAnd && c.Logic != LogicOr {
+			return apperr.Wrap(apperr.ErrInvalidInput, "invalid query logic", fmt.Errorf("logic %q", c.Logic))
+		}
+		for _, sub := range c.Conditions {
+			if err := validateCondition(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Predicate:
+		if err := ValidateField(c.Field); err != nil {
+			return err
+		}
+		switch c.Op {
+		case OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpIsNull, OpIsNotNull:
+			return nil
+		case OpIn:
+			if _, ok := c.Value.([]any); !ok {
+				return apperr.Wrap(apperr.ErrInvalidInput, "invalid query value", fmt.Errorf("IN on %q expects []any", c.Field))
+			}
+			return nil
+		case OpContains, OpStartsWith:
+			if _, ok := c.Value.(string); !ok {
+				return apperr.Wrap(apperr.ErrInvalidInput, "invalid query value", fmt.Errorf("%s on %q expects a string", c.Op, c.Field))
+			}
+			return nil
+		}
+		return apperr.Wrap(apperr.ErrInvalidInput, "invalid query operator", fmt.Errorf("operator %q", c.Op))
+	}
+	return apperr.Wrap(apperr.ErrInvalidInput, "invalid query condition", fmt.Errorf("type %T", c))
+}
@@ -11,19 +11,60 @@ type Entity interface {
 	GetTenantID() string
 }
 
-type UserFilter struct {
-	Category *string
-	MinAge   *int
-	IsActive *bool
-	Limit    int
-	Offset   int // Pour la pagination
+// Versioned est implémentée (optionnellement) par les entités qui veulent la concurrence optimiste.
+// L'adaptateur renseigne l'ETag après chaque lecture/écriture et l'envoie en If-Match lors des
+// mises à jour : une écriture concurrente échoue alors avec ErrPreconditionFailed.
+// SetETag doit avoir un receveur pointeur, l'adaptateur l'appelle sur *T.
+type Versioned interface {
+	GetETag() string
+	SetETag(etag string)
+}
+
+// WriteOptions regroupe les préconditions d'une écriture.
+type WriteOptions struct {
+	// IfMatch est l'ETag attendu. Vide = écriture inconditionnelle.
+	IfMatch string
+}
+
+// WriteOption modifie les WriteOptions d'une écriture (Update, Delete).
+type WriteOption func(*WriteOptions)
+
+// IfMatch conditionne l'écriture à l'ETag donné.
+func IfMatch(etag string) WriteOption {
+	return func(o *WriteOptions) {
+		o.IfMatch = etag
+	}
+}
+
+// ApplyWriteOptions construit les WriteOptions pour item : l'ETag de l'entité (si Versioned)
+// sert de valeur par défaut, les options explicites sont prioritaires.
+func ApplyWriteOptions[T Entity](item *T, opts []WriteOption) WriteOptions {
+	var o WriteOptions
+	if item != nil {
+		if v, ok := any(item).(Versioned); ok {
+			o.IfMatch = v.GetETag()
+		}
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// SetETag renseigne l'ETag sur item si son type est Versioned (no-op sinon).
+func SetETag[T Entity](item *T, etag string) {
+	if v, ok := any(item).(Versioned); ok {
+		v.SetETag(etag)
+	}
 }
 
 // Repository définit les opérations CRUD standard.
+// Create et Update renvoient l'entité telle que stockée (avec son nouvel ETag si elle est Versioned).
 type Repository[T Entity] interface {
-	Create(ctx context.Context, item T) error
+	Create(ctx context.Context, item T) (T, error)
 	Read(ctx context.Context, id string, partitionKey string) (T, error)
-	Update(ctx context.Context, item T) error
-	Delete(ctx context.Context, id string, partitionKey string) error
-	Search(ctx context.Context, filter UserFilter, partitionKey string) ([]T, error)
+	Update(ctx context.Context, item T, opts ...WriteOption) (T, error)
+	Delete(ctx context.Context, id string, partitionKey string, opts ...WriteOption) error
+	// Search renvoie une page de résultats ; la page suivante se demande avec query.Page(limit, page.Continuation).
+	Search(ctx context.Context, query Query[T], partitionKey string) (Page[T], error)
 }
@@ -0,0 +1,255 @@
+// Package health expose les sondes de l'API : /health/live (le processus répond) et
+// /health/ready (l'instance peut recevoir du trafic). Les composants y enregistrent des
+// vérifications nommées (base de données, fournisseur d'identité, tâches de fond...), chacune
+// bornée par un délai et mise en cache pour que les sondes ne coûtent pas une requête par appel.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"test-api/kit/logger"
+)
+
+// Status est l'état d'une vérification ou de l'instance.
+type Status string
+
+const (
+	// StatusUp : tout fonctionne.
+	StatusUp Status = "up"
+	// StatusDegraded : une dépendance non critique est en échec ; l'instance reste prête,
+	// avec un service dégradé (ex : clés de signature en cache mais plus rafraîchies).
+	StatusDegraded Status = "degraded"
+	// StatusDown : une dépendance critique est en échec ; l'instance ne doit plus recevoir de trafic.
+	StatusDown Status = "down"
+)
+
+const (
+	// DefaultTimeout borne chaque vérification : une dépendance qui ne répond pas est en échec.
+	DefaultTimeout = 2 * time.Second
+	// DefaultCacheTTL est la durée de réutilisation d'un résultat. Les sondes de la plateforme
+	// passent toutes les quelques secondes, sur chaque instance : sans cache, chaque appel
+	// coûterait une lecture Cosmos (RU facturées).
+	DefaultCacheTTL = 10 * time.Second
+)
+
+// ErrTimeout est le résultat d'une vérification qui n'a pas répondu dans son délai.
+var ErrTimeout = errors.New("health: check timed out")
+
+// Checker vérifie une dépendance ; une erreur la signale en échec. Le message de l'erreur
+// apparaît dans la réponse des sondes : il ne doit pas contenir de secret.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapte une fonction en Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error { return f(ctx) }
+
+// =================================================================================
+// Registre des vérifications
+// =================================================================================
+
+// CheckOption configure une vérification.
+type CheckOption func(*check)
+
+// WithTimeout remplace DefaultTimeout.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithCacheTTL remplace DefaultCacheTTL ; 0 désactive le cache (vérification en mémoire, sans coût).
+func WithCacheTTL(d time.Duration) CheckOption {
+	return func(c *check) { c.cacheTTL = d }
+}
+
+// NonCritical signale une dépendance dont l'échec dégrade le service sans le rendre indisponible :
+// l'instance reste prête (StatusDegraded).
+func NonCritical() CheckOption {
+	return func(c *check) { c.critical = false }
+}
+
+// Liveness inclut la vérification dans /health/live : son échec signifie que le processus est
+// bloqué et doit être redémarré (ex : tâche de fond qui ne progresse plus). Les dépendances
+// externes n'ont rien à y faire : redémarrer l'instance ne répare pas une base injoignable.
+func Liveness() CheckOption {
+	return func(c *check) { c.liveness = true }
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+	critical bool
+	liveness bool
+
+	// mu sérialise les exécutions : des sondes simultanées partagent le même résultat.
+	mu   sync.Mutex
+	last *Result
+}
+
+// Registry regroupe les vérifications enregistrées par les composants au démarrage.
+type Registry struct {
+	now func() time.Time
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{now: time.Now}
+}
+
+// Register ajoute une vérification. Par défaut, elle est critique, bornée par DefaultTimeout
+// et mise en cache pendant DefaultCacheTTL.
+func (r *Registry) Register(name string, checker Checker, opts ...CheckOption) {
+	c := &check{
+		name:     name,
+		checker:  checker,
+		timeout:  DefaultTimeout,
+		cacheTTL: DefaultCacheTTL,
+		critical: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+}
+
+// =================================================================================
+// Rapports
+// =================================================================================
+
+// Result est le résultat d'une vérification.
+type Result struct {
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"durationMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// Report est l'état de l'instance, avec le détail par vérification.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Live exécute les vérifications de vivacité (voir Liveness).
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.report(ctx, true)
+}
+
+// Ready exécute toutes les vérifications.
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.report(ctx, false)
+}
+
+func (r *Registry) report(ctx context.Context, livenessOnly bool) Report {
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if !livenessOnly || c.liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	// Vérifications en parallèle : la sonde dure autant que la plus lente, pas que leur somme.
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() { results[i] = r.run(ctx, c) })
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		switch {
+		case results[i].Status != StatusDown:
+		case c.critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run exécute la vérification, ou réutilise son dernier résultat s'il est encore valable.
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && r.now().Sub(c.last.CheckedAt) < c.cacheTTL {
+		return *c.last
+	}
+
+	start := r.now()
+	err := runWithTimeout(ctx, c.checker, c.timeout)
+	result := Result{Status: StatusUp, DurationMs: float64(r.now().Sub(start).Microseconds()) / 1000, CheckedAt: start}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		logger.Warn(ctx, "Health check failed", "check", c.name, "critical", c.critical, "error", err)
+	}
+	c.last = &result
+	return result
+}
+
+// runWithTimeout n'attend pas au-delà du délai, même si le Checker ignore son contexte.
+func runWithTimeout(ctx context.Context, checker Checker, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- checker.Check(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
+
+// =================================================================================
+// Handlers HTTP
+// =================================================================================
+
+// LiveHandler sert /health/live : 200 tant que le processus répond et que ses vérifications
+// de vivacité passent, 503 sinon.
+func (r *Registry) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		respond(w, r.Live(req.Context()))
+	})
+}
+
+// ReadyHandler sert /health/ready : 200 si l'instance est prête (y compris dégradée),
+// 503 si une dépendance critique est en échec.
+func (r *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		respond(w, r.Ready(req.Context()))
+	})
+}
+
+func respond(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if report.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// Les sondes doivent voir l'état courant, jamais une réponse mise en cache par un proxy.
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,107 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/health"
+)
+
+func TestReadyHandler(t *testing.T) {
+	var cosmosErr, jwksErr error
+	checkerOf := func(err *error) health.CheckerFunc {
+		return func(context.Context) error { return *err }
+	}
+
+	registry := health.NewRegistry()
+	registry.Register("cosmos", checkerOf(&cosmosErr), health.WithCacheTTL(0))
+	registry.Register("jwks", checkerOf(&jwksErr), health.WithCacheTTL(0), health.NonCritical())
+
+	probe := func(t *testing.T, handler http.Handler) (int, health.Report) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		var report health.Report
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		return rr.Code, report
+	}
+
+	tests := []struct {
+		name           string
+		cosmos, jwks   error
+		expectedCode   int
+		expectedStatus health.Status
+	}{
+		{"Tout va bien", nil, nil, http.StatusOK, health.StatusUp},
+		{"Dépendance non critique en échec", nil, errors.New("jwks stale"), http.StatusOK, health.StatusDegraded},
+		{"Dépendance critique en échec", errors.New("cosmos unreachable"), nil, http.StatusServiceUnavailable, health.StatusDown},
+		{"Les deux en échec", errors.New("cosmos unreachable"), errors.New("jwks stale"), http.StatusServiceUnavailable, health.StatusDown},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cosmosErr, jwksErr = tc.cosmos, tc.jwks
+
+			code, report := probe(t, registry.ReadyHandler())
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedStatus, report.Status)
+			require.Contains(t, report.Checks, "cosmos")
+			if tc.cosmos != nil {
+				assert.Equal(t, tc.cosmos.Error(), report.Checks["cosmos"].Error)
+			}
+		})
+	}
+
+	t.Run("Vivacité indépendante des dépendances", func(t *testing.T) {
+		cosmosErr = errors.New("cosmos unreachable")
+		code, report := probe(t, registry.LiveHandler())
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, health.StatusUp, report.Status)
+		assert.Empty(t, report.Checks)
+	})
+}
+
+func TestRegistry_CacheAndTimeout(t *testing.T) {
+	var calls atomic.Int32
+	registry := health.NewRegistry()
+	registry.Register("cosmos", health.CheckerFunc(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}), health.WithCacheTTL(time.Minute))
+	registry.Register("lent", health.CheckerFunc(func(context.Context) error {
+		time.Sleep(time.Second) // ignore son contexte
+		return nil
+	}), health.WithTimeout(20*time.Millisecond), health.NonCritical())
+
+	start := time.Now()
+	report := registry.Ready(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "le délai borne la vérification")
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, health.ErrTimeout.Error(), report.Checks["lent"].Error)
+
+	registry.Ready(context.Background())
+	registry.Ready(context.Background())
+	assert.Equal(t, int32(1), calls.Load(), "résultat réutilisé tant que le cache est valable")
+}
+
+func TestHeartbeat(t *testing.T) {
+	heartbeat := health.NewHeartbeat(30 * time.Millisecond)
+	registry := health.NewRegistry()
+	registry.Register("worker", heartbeat, health.Liveness(), health.WithCacheTTL(0))
+
+	assert.Equal(t, health.StatusUp, registry.Live(context.Background()).Status)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, health.StatusDown, registry.Live(context.Background()).Status, "tâche bloquée")
+
+	heartbeat.Beat()
+	assert.Equal(t, health.StatusUp, registry.Live(context.Background()).Status)
+}
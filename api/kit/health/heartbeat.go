@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat surveille une tâche de fond : la tâche appelle Beat à chaque itération, et la
+// vérification échoue si le dernier battement date de plus de maxAge (tâche bloquée ou arrêtée).
+// À enregistrer avec Liveness et WithCacheTTL(0).
+type Heartbeat struct {
+	maxAge time.Duration
+	now    func() time.Time
+	last   atomic.Int64
+}
+
+// NewHeartbeat crée un Heartbeat ; la tâche est considérée vivante à sa création.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	h := &Heartbeat{maxAge: maxAge, now: time.Now}
+	h.Beat()
+	return h
+}
+
+// Beat signale que la tâche progresse.
+func (h *Heartbeat) Beat() {
+	h.last.Store(h.now().UnixNano())
+}
+
+func (h *Heartbeat) Check(context.Context) error {
+	age := h.now().Sub(time.Unix(0, h.last.Load()))
+	if age > h.maxAge {
+		return fmt.Errorf("health: no heartbeat for %s (max %s)", age.Round(time.Second), h.maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DefaultRedactedParams sont les paramètres de requête masqués dans le log d'accès.
+var DefaultRedactedParams = []string{
+	"access_token", "api_key", "apikey", "code", "key", "password", "refresh_token", "secret", "sig", "signature", "token",
+}
+
+// accessSampleRate est la proportion des réponses 2xx/3xx journalisées (voir Config.AccessLogSampleRate).
+var accessSampleRate = 1.0
+
+// AccessLogOption configure le log d'accès.
+type AccessLogOption func(*accessLog)
+
+// WithSuccessSampleRate journalise seulement cette proportion (entre 0 et 1) des réponses 2xx/3xx.
+// Les 4xx et 5xx sont toujours journalisées.
+func WithSuccessSampleRate(rate float64) AccessLogOption {
+	return func(a *accessLog) {
+		a.sampleRate = rate
+	}
+}
+
+// WithRedactedParams masque aussi ces paramètres de requête (insensible à la casse).
+func WithRedactedParams(names ...string) AccessLogOption {
+	return func(a *accessLog) {
+		for _, name := range names {
+			a.redacted[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+type accessLog struct {
+	sampleRate float64
+	redacted   map[string]struct{}
+}
+
+// sampled indique si une réponse réussie doit être journalisée.
+func (a *accessLog) sampled() bool {
+	return a.sampleRate >= 1 || rand.Float64() < a.sampleRate
+}
+
+// requestAttrs collecte les attributs connus en cours de requête (tenant, utilisateur...) :
+// la ligne d'accès est émise avec le contexte d'entrée, qui ne les voit pas.
+type requestAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+type requestAttrsKey struct{}
+
+// WithRequestAttrs est WithAttrs pour les attributs qui décrivent toute la requête : ils figurent
+// aussi sur la ligne du log d'accès, bien qu'ajoutés par un middleware placé après AccessLog
+// (ex : l'authentification ajoute le tenant et l'utilisateur).
+func WithRequestAttrs(ctx context.Context, args ...any) context.Context {
+	if holder, ok := ctx.Value(requestAttrsKey{}).(*requestAttrs); ok {
+		added := slog.Group("", args...).Value.Group()
+		holder.mu.Lock()
+		holder.attrs = append(holder.attrs, added...)
+		holder.mu.Unlock()
+	}
+	return WithAttrs(ctx, args...)
+}
+
+// AccessLog émet une ligne par requête : méthode, route chi (le motif "/api/users/{id}" et non
+// le chemin, pour borner la cardinalité et ne pas journaliser d'identifiants ou de jetons), statut,
+// durée et taille de la réponse. Le niveau suit le statut : INFO, WARN (4xx) ou ERROR (5xx).
+// À placer après Middleware, pour que la ligne porte les attributs de la requête ; ceux ajoutés
+// plus loin avec WithRequestAttrs y figurent aussi.
+func AccessLog(opts ...AccessLogOption) func(http.Handler) http.Handler {
+	a := &accessLog{
+		sampleRate: accessSampleRate,
+		redacted:   make(map[string]struct{}, len(DefaultRedactedParams)),
+	}
+	WithRedactedParams(DefaultRedactedParams...)(a)
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			holder := &requestAttrs{}
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), requestAttrsKey{}, holder)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			default:
+				if !a.sampled() {
+					return
+				}
+			}
+
+			// La route est ajoutée par le handler de contexte (attribut "route").
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.Int("status", status),
+				slog.Float64("durationMs", float64(time.Since(start).Microseconds())/1000),
+				slog.Int("bytes", ww.BytesWritten()),
+			}
+			if r.URL.RawQuery != "" {
+				attrs = append(attrs, slog.String("query", a.redactQuery(r.URL.RawQuery)))
+			}
+			if ua := r.UserAgent(); ua != "" {
+				attrs = append(attrs, slog.String("userAgent", ua))
+			}
+			holder.mu.Lock()
+			attrs = append(attrs, holder.attrs...)
+			holder.mu.Unlock()
+
+			slog.Default().LogAttrs(r.Context(), level, "HTTP request", attrs...)
+		})
+	}
+}
+
+// redactQuery remplace la valeur des paramètres sensibles par "REDACTED".
+func (a *accessLog) redactQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Requête mal formée : rien n'est journalisé plutôt qu'un secret en clair.
+		return "REDACTED"
+	}
+	for name := range values {
+		if _, ok := a.redacted[strings.ToLower(name)]; ok {
+			values[name] = []string{"REDACTED"}
+		}
+	}
+	return values.Encode()
+}
@@ -2,108 +2,164 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Clé pour stocker le logger dans le contexte
-type ctxKey struct{}
+// Formats de sortie acceptés par Config.Format.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
 
-// Init configure le logger JSON par défaut au démarrage
-func Init() {
-	opts := &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-		// AddSource: true, // Décommente si tu veux le nom du fichier et la ligne dans les logs
-	}
-	handler := slog.NewJSONHandler(os.Stdout, opts)
-	slog.SetDefault(slog.New(handler))
+// Config décrit la sortie des logs.
+type Config struct {
+	// Level est le niveau minimal journalisé (INFO par défaut).
+	Level slog.Level
+	// Format vaut FormatJSON (défaut, parsé automatiquement par Azure Log Analytics) ou FormatText (lecture locale).
+	Format string
+	// Output est la destination des logs (os.Stdout par défaut).
+	Output io.Writer
+	// AccessLogSampleRate est la proportion des requêtes réussies (2xx/3xx) présentes dans le
+	// log d'accès (0 : aucune) ; les erreurs y figurent toujours.
+	AccessLogSampleRate float64
 }
 
-// Middleware : C'est lui qui injecte l'OperationID d'Azure
-func Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 1. Récupération de l'ID Azure
-		traceID := ""
-		traceParent := r.Header.Get("traceparent")
-		if traceParent != "" {
-			parts := strings.Split(traceParent, "-")
-			if len(parts) > 1 {
-				traceID = parts[1]
-			}
-		}
-
-		// 2. Création d'un logger enrichi
-		var l *slog.Logger
-		if traceID != "" {
-			l = slog.Default().With("operation_Id", traceID)
-		} else {
-			l = slog.Default()
-		}
+// Init installe le logger par défaut au démarrage. Tous les logs (y compris slog.Default)
+// passent ensuite par le handler de contexte, qui ajoute les attributs de la requête.
+func Init(cfg Config) error {
+	h, err := NewHandler(cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	accessSampleRate = cfg.AccessLogSampleRate
+	return nil
+}
 
-		// 3. Injection dans le contexte
-		ctx := context.WithValue(r.Context(), ctxKey{}, l)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// NewHandler construit le handler slog décrit par cfg, enrichi des attributs de contexte.
+func NewHandler(cfg Config) (slog.Handler, error) {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+
+	var h slog.Handler
+	switch cfg.Format {
+	case "", FormatJSON:
+		h = slog.NewJSONHandler(out, opts)
+	case FormatText:
+		h = slog.NewTextHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (expected %q or %q)", cfg.Format, FormatJSON, FormatText)
+	}
+	return contextHandler{Handler: h}, nil
 }
 
 // =============================================================================
-// API PROPRE (Helpers)
+// Attributs de requête
 // =============================================================================
 
-// Info logge un message au niveau INFO en récupérant l'ID du contexte
-func Info(ctx context.Context, msg string, args ...any) {
-	getLogger(ctx).Info(msg, args...)
+// Clé pour stocker les attributs de la requête dans le contexte
+type ctxKey struct{}
+
+// WithAttrs ajoute des attributs (paires clé/valeur, comme slog) à tous les logs émis
+// avec le contexte retourné. Exemple : le middleware d'authentification y ajoute le tenant.
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	current := attrsFrom(ctx)
+	added := slog.Group("", args...).Value.Group()
+	merged := make([]slog.Attr, 0, len(current)+len(added))
+	merged = append(merged, current...)
+	merged = append(merged, added...)
+	return context.WithValue(ctx, ctxKey{}, merged)
 }
 
-// Error logge un message au niveau ERROR en récupérant l'ID du contexte
-func Error(ctx context.Context, msg string, args ...any) {
-	getLogger(ctx).Error(msg, args...)
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
 }
 
-// Warn logge un message au niveau WARN
-func Warn(ctx context.Context, msg string, args ...any) {
-	getLogger(ctx).Warn(msg, args...)
+// contextHandler ajoute à chaque enregistrement les attributs du contexte, la trace en cours
+// et la route chi (connue seulement une fois le routage effectué, d'où sa lecture au moment du log).
+type contextHandler struct {
+	slog.Handler
 }
 
-// getLogger récupère le logger du contexte ou renvoie le défaut
-func getLogger(ctx context.Context) *slog.Logger {
-	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
-		return l
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(attrsFrom(ctx)...)
+	if ctx != nil {
+		// Noms de colonnes d'Application Insights : la trace, et le span parent du log.
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(
+				slog.String("operation_Id", sc.TraceID().String()),
+				slog.String("operation_ParentId", sc.SpanID().String()),
+			)
+		}
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				r.AddAttrs(slog.String("route", pattern))
+			}
+		}
 	}
-	return slog.Default()
+	return h.Handler.Handle(ctx, r)
 }
 
-/*
-TODO si on veut utiliser quelque chose de plus propre
-dans main
-import "test-api/kit/logger" // Importe ton nouveau package
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
 
-func main() {
-    logger.Init() // Juste une fois au début
-    // ...
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
 }
 
-dans router.go:
-r.Use(logger.Middleware) // Ajoute le middleware
+// Middleware ajoute l'ID de requête aux logs. L'identifiant de trace (operation_Id) vient du
+// span posé par tracing.Middleware ; sans lui, le traceparent entrant est repris s'il est valide.
+// À placer après middleware.RequestID et tracing.Middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = propagation.TraceContext{}.Extract(ctx, propagation.HeaderCarrier(r.Header))
+		}
+		if id := middleware.GetReqID(ctx); id != "" {
+			ctx = WithAttrs(ctx, "requestID", id)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-Dans tes Handlers (Health, Users, etc.)
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-    // UTILISATION SIMPLE :
-    logger.Info(r.Context(), "Health check déclenché")
+// =============================================================================
+// API PROPRE (Helpers)
+// =============================================================================
 
-    // AVEC DES ATTRIBUTS :
-    logger.Info(r.Context(), "Vérification DB",
-        "status", "OK",
-        "latence_ms", 12,
-    )
+// Debug logge un message au niveau DEBUG
+func Debug(ctx context.Context, msg string, args ...any) {
+	slog.Default().DebugContext(ctx, msg, args...)
+}
 
-    // EN CAS D'ERREUR :
-    if err != nil {
-        logger.Error(r.Context(), "Problème critique", "error", err)
-    }
+// Info logge un message au niveau INFO avec les attributs de la requête
+func Info(ctx context.Context, msg string, args ...any) {
+	slog.Default().InfoContext(ctx, msg, args...)
+}
 
-    w.Write([]byte("OK"))
+// Warn logge un message au niveau WARN
+func Warn(ctx context.Context, msg string, args ...any) {
+	slog.Default().WarnContext(ctx, msg, args...)
+}
+
+// Error logge un message au niveau ERROR avec les attributs de la requête
+func Error(ctx context.Context, msg string, args ...any) {
+	slog.Default().ErrorContext(ctx, msg, args...)
 }
-*/
@@ -0,0 +1,151 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/logger"
+)
+
+func TestMiddleware_RequestAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := logger.NewHandler(logger.Config{Output: &buf})
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, logger.Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		ctx := logger.WithAttrs(req.Context(), "tenantID", "tenant-A")
+		logger.Info(ctx, "lecture")
+		// slog direct (bibliothèques tierces) : mêmes attributs dès lors que le contexte est fourni.
+		slog.WarnContext(ctx, "attention")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["operation_Id"])
+		assert.NotEmpty(t, entry["requestID"])
+		assert.Equal(t, "tenant-A", entry["tenantID"])
+		assert.Equal(t, "/users/{id}", entry["route"])
+	}
+}
+
+func TestNewHandler_InvalidFormat(t *testing.T) {
+	_, err := logger.NewHandler(logger.Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := logger.NewHandler(logger.Config{Output: &buf})
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	newRouter := func(opts ...logger.AccessLogOption) http.Handler {
+		r := chi.NewRouter()
+		r.Use(logger.Middleware, logger.AccessLog(opts...))
+		r.Get("/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+			switch chi.URLParam(req, "id") {
+			case "absent":
+				w.WriteHeader(http.StatusNotFound)
+			case "panne":
+				w.WriteHeader(http.StatusBadGateway)
+			case "authentifie":
+				// Comme le middleware d'authentification, monté après le log d'accès.
+				ctx := logger.WithRequestAttrs(req.Context(), "tenantID", "tenant-A", "userID", "u-1")
+				logger.Info(ctx, "lecture")
+			default:
+				_, _ = w.Write([]byte("arthur"))
+			}
+		})
+		return r
+	}
+	lastEntry := func(t *testing.T) map[string]any {
+		t.Helper()
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+		return entry
+	}
+
+	tests := []struct {
+		name          string
+		target        string
+		expectedLevel string
+		expectedCode  float64
+	}{
+		{"Succès", "/users/42", "INFO", http.StatusOK},
+		{"Erreur client", "/users/absent", "WARN", http.StatusNotFound},
+		{"Erreur serveur", "/users/panne", "ERROR", http.StatusBadGateway},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			newRouter().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			entry := lastEntry(t)
+			assert.Equal(t, "HTTP request", entry["msg"])
+			assert.Equal(t, tc.expectedLevel, entry["level"])
+			assert.Equal(t, tc.expectedCode, entry["status"])
+			assert.Equal(t, "GET", entry["method"])
+			// Le motif de route, jamais le chemin brut.
+			assert.Equal(t, "/users/{id}", entry["route"])
+			assert.NotContains(t, buf.String(), tc.target)
+			assert.Contains(t, entry, "durationMs")
+		})
+	}
+
+	t.Run("Paramètres sensibles masqués", func(t *testing.T) {
+		buf.Reset()
+		newRouter(logger.WithRedactedParams("otp")).ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(http.MethodGet, "/users/42?token=s3cr3t&OTP=123456&limit=10", nil))
+
+		query := lastEntry(t)["query"].(string)
+		assert.Contains(t, query, "limit=10")
+		assert.NotContains(t, query, "s3cr3t")
+		assert.NotContains(t, query, "123456")
+		assert.Equal(t, float64(6), lastEntry(t)["bytes"])
+	})
+
+	t.Run("Attributs ajoutés en cours de requête", func(t *testing.T) {
+		buf.Reset()
+		newRouter().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/authentifie", nil))
+
+		entry := lastEntry(t)
+		assert.Equal(t, "HTTP request", entry["msg"])
+		assert.Equal(t, "tenant-A", entry["tenantID"])
+		assert.Equal(t, "u-1", entry["userID"])
+	})
+
+	t.Run("Échantillonnage des succès", func(t *testing.T) {
+		buf.Reset()
+		r := newRouter(logger.WithSuccessSampleRate(0))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+		assert.Empty(t, buf.String())
+
+		// Les erreurs sont toujours journalisées.
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/absent", nil))
+		assert.Equal(t, "WARN", lastEntry(t)["level"])
+	})
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// DurationBuckets sont les bornes (en secondes) des histogrammes de latence.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Middleware compte les requêtes et mesure leur durée par méthode, route chi et statut
+// (http_server_requests_total, http_server_request_duration_seconds). La route, et non le
+// chemin, borne le nombre de séries. Le middleware doit être monté sur le routeur racine.
+func Middleware(next http.Handler) http.Handler {
+	meter := otel.Meter(instrumentation)
+	requests, _ := meter.Int64Counter("http.server.requests",
+		metric.WithDescription("Number of HTTP requests handled."),
+		metric.WithUnit("{request}"),
+	)
+	duration, _ := meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP requests."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(DurationBuckets...),
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		// Requêtes sans route (404) regroupées sous une seule valeur.
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		attrs := metric.WithAttributeSet(attribute.NewSet(
+			semconv.HTTPRequestMethodKey.String(methodLabel(r.Method)),
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(status),
+		))
+		requests.Add(r.Context(), 1, attrs)
+		duration.Record(r.Context(), time.Since(start).Seconds(), attrs)
+	})
+}
+
+// methodLabel ramène les méthodes non standard à "_OTHER" (convention OpenTelemetry),
+// pour qu'un client ne puisse pas créer une série par méthode inventée.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "_OTHER"
+}
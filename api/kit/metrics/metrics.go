@@ -0,0 +1,99 @@
+// Package metrics expose les métriques de l'API au format Prometheus (/metrics) et, au besoin,
+// les exporte en OTLP. Les métriques sont produites avec l'API OpenTelemetry (otel.Meter) :
+// les paquets instrumentés ne dépendent pas de Prometheus.
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+
+	"test-api/kit/api"
+	"test-api/kit/apperr"
+)
+
+const instrumentation = "test-api/kit/metrics"
+
+// Config décrit l'exposition des métriques.
+type Config struct {
+	// ServiceName identifie l'API dans les métriques exportées en OTLP.
+	ServiceName string
+	// OTLP active en plus l'export périodique OTLP (OTEL_EXPORTER_OTLP_ENDPOINT...).
+	OTLP bool
+	// Token protège /metrics (en-tête "Authorization: Bearer <token>"). Vide, l'endpoint n'est pas exposé.
+	Token string
+}
+
+// Init installe le fournisseur de métriques global et retourne le handler /metrics (au format
+// Prometheus, protégé par cfg.Token, nil si aucun jeton n'est configuré) ainsi que la fonction
+// d'arrêt, qui envoie les dernières mesures OTLP.
+func Init(ctx context.Context, cfg Config) (handler http.Handler, shutdown func(context.Context) error, err error) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	promExporter, err := otelprom.New(otelprom.WithRegisterer(registry), otelprom.WithoutScopeInfo())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+	}
+	opts := []metric.Option{metric.WithReader(promExporter)}
+
+	if cfg.OTLP {
+		otlpExporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		opts = append(opts, metric.WithReader(metric.NewPeriodicReader(otlpExporter)))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, nil, fmt.Errorf("failed to build metric resource: %w", err)
+	}
+	opts = append(opts, metric.WithResource(res))
+
+	provider := metric.NewMeterProvider(opts...)
+	otel.SetMeterProvider(provider)
+
+	if cfg.Token != "" {
+		handler = RequireToken(cfg.Token)(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	}
+	return handler, provider.Shutdown, nil
+}
+
+// errInvalidMetricsToken est renvoyée à tout appel de /metrics sans le bon jeton.
+var errInvalidMetricsToken = apperr.New(apperr.ErrUnauthorized, "invalid metrics token")
+
+// RequireToken réserve l'accès au porteur du jeton (collecteur Prometheus). Les métriques
+// couvrent tous les tenants : elles ne sont pas accessibles avec un jeton utilisateur.
+func RequireToken(token string) func(http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				api.RespondWithError(w, r, errInvalidMetricsToken)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
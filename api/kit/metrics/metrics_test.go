@@ -0,0 +1,68 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"test-api/kit/metrics"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	handler, shutdown, err := metrics.Init(context.Background(), metrics.Config{ServiceName: "test-api", Token: "scraper-token"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+	require.NotNil(t, handler)
+
+	r := chi.NewRouter()
+	r.Use(metrics.Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Method(http.MethodGet, "/metrics", handler)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/43", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/users/43", nil))
+
+	// 1. Sans le jeton du collecteur : refusé.
+	for _, authorization := range []string{"", "Bearer wrong", "scraper-token"} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Authorization", authorization)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, authorization)
+	}
+
+	// 2. Avec le jeton : format texte Prometheus, une série par route (pas par chemin).
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scraper-token")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	body, _ := io.ReadAll(rr.Body)
+
+	assert.Contains(t, string(body), `http_server_requests_total{http_request_method="GET",http_response_status_code="200",http_route="/users/{id}"`)
+	assert.Contains(t, string(body), `http_server_request_duration_seconds_bucket{http_request_method="GET",http_response_status_code="200",http_route="/users/{id}"`)
+	assert.Contains(t, string(body), `http_request_method="_OTHER"`)
+	assert.NotContains(t, string(body), "/users/42")
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+func TestInit_WithoutToken(t *testing.T) {
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	handler, shutdown, err := metrics.Init(context.Background(), metrics.Config{ServiceName: "test-api"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = shutdown(context.Background()) })
+	assert.Nil(t, handler, "sans jeton, /metrics n'est pas exposé")
+}
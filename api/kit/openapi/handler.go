@@ -0,0 +1,26 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/swaggest/swgui/v5emb"
+)
+
+// Handler sert le document en JSON. Il est encodé une seule fois : le document est figé au démarrage.
+func Handler(doc *Document) (http.Handler, error) {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}), nil
+}
+
+// UIHandler sert l'interface Swagger UI (embarquée dans le binaire, sans CDN) sous basePath,
+// alimentée par le document servi à specURL.
+func UIHandler(title, specURL, basePath string) http.Handler {
+	return v5emb.New(title, specURL, basePath)
+}
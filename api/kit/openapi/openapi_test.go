@@ -0,0 +1,84 @@
+package openapi_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/openapi"
+)
+
+type audit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type invoice struct {
+	audit
+	ID       string            `json:"id"`
+	Total    float64           `json:"total"`
+	Status   string            `json:"status" enum:"draft,sent"`
+	PaidAt   *time.Time        `json:"paidAt,omitempty"`
+	Lines    []line            `json:"lines"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Secret   string            `json:"secret" openapi:"-"`
+	Ignored  string            `json:"-"`
+}
+
+type line struct {
+	Label    string `json:"label"`
+	Quantity uint   `json:"quantity"`
+}
+
+func TestBuild(t *testing.T) {
+	r := chi.NewRouter()
+	r.Route("/invoices", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		r.Get("/{id:[a-z0-9-]+}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	spec := openapi.New(openapi.Info{Title: "test", Version: "1"}, struct{ Title string }{}, "application/problem+json")
+	invoices := spec.Group("/invoices")
+	invoices.Add(http.MethodGet, "/", openapi.Operation{
+		OperationID: "listInvoices",
+		Responses:   map[string]*openapi.Response{"200": invoices.JSON("Factures", []invoice{})},
+	})
+	invoices.Add(http.MethodGet, "/{id:[a-z0-9-]+}", openapi.Operation{OperationID: "getInvoice"})
+
+	doc, err := spec.Build(r)
+	require.NoError(t, err)
+
+	// Chemins sans barre finale ni expression régulière, paramètres de chemin déduits du motif.
+	require.Contains(t, doc.Paths, "/invoices")
+	get := doc.Paths["/invoices/{id}"]["get"]
+	require.NotNil(t, get)
+	require.Len(t, get.Parameters, 1)
+	assert.Equal(t, openapi.Parameter{Name: "id", In: openapi.InPath, Required: true, Schema: &openapi.Schema{Type: "string"}}, *get.Parameters[0])
+	assert.Equal(t, "#/components/responses/"+openapi.ProblemResponse, get.Responses["default"].Ref)
+
+	schema := doc.Components.Schemas["Invoice"]
+	require.NotNil(t, schema)
+	assert.ElementsMatch(t, []string{"createdAt", "id", "total", "status", "lines"}, schema.Required)
+	assert.NotContains(t, schema.Properties, "secret")
+	assert.NotContains(t, schema.Properties, "Ignored")
+	assert.Equal(t, &openapi.Schema{Type: "string", Format: "date-time"}, schema.Properties["paidAt"])
+	assert.Equal(t, []any{"draft", "sent"}, schema.Properties["status"].Enum)
+	assert.Equal(t, "#/components/schemas/Line", schema.Properties["lines"].Items.Ref)
+	assert.Contains(t, doc.Components.Schemas, "Line")
+}
+
+func TestBuild_RouterDrift(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/invoices", func(w http.ResponseWriter, r *http.Request) {})
+
+	spec := openapi.New(openapi.Info{Title: "test", Version: "1"}, struct{}{}, "application/problem+json")
+	spec.Add(http.MethodPost, "/invoices", openapi.Operation{OperationID: "createInvoice"})
+
+	_, err := spec.Build(r)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "route GET /invoices is not documented")
+	assert.Contains(t, err.Error(), "documented operation POST /invoices has no route")
+}
@@ -0,0 +1,154 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// =================================================================================
+// Schémas JSON déduits des types Go
+// =================================================================================
+
+var (
+	timeType = reflect.TypeFor[time.Time]()
+	rawType  = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemas génère les schémas des structures nommées dans components.schemas, une fois par type.
+//
+// Les champs suivent les règles d'encoding/json (tag json, "-", omitempty, champs embarqués).
+// Un champ est requis s'il n'est ni omitempty ni pointeur. Deux tags complètent la description :
+//   - openapi:"-" masque un champ jamais exposé par l'API (empreinte de mot de passe...) ;
+//   - enum:"a,b,c" liste les valeurs admises.
+type schemas struct {
+	names      map[reflect.Type]string
+	components map[string]*Schema
+}
+
+func newSchemas() *schemas {
+	return &schemas{names: map[reflect.Type]string{}, components: map[string]*Schema{}}
+}
+
+// of retourne le schéma de t : une référence pour les structures nommées, le schéma lui-même sinon.
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // []byte est encodé en base64
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		// interface{} et types non représentables : n'importe quelle valeur JSON.
+		return &Schema{}
+	}
+}
+
+// component enregistre le schéma d'une structure nommée et retourne son nom.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := exported(t.Name())
+	if _, taken := s.components[name]; taken {
+		// Même nom dans deux domaines : le second est préfixé par son paquet (ex : ApikeySearchResponse).
+		name = exported(path.Base(t.PkgPath())) + name
+	}
+	// Nom réservé avant de décrire les champs : les types récursifs se référencent eux-mêmes.
+	s.names[t] = name
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Tag.Get("openapi") == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded) // champs promus, comme encoding/json
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.of(field.Type)
+		if values := field.Tag.Get("enum"); values != "" {
+			for _, v := range strings.Split(values, ",") {
+				prop.Enum = append(prop.Enum, v)
+			}
+		}
+		schema.Properties[name] = prop
+
+		omitempty := strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,")
+		if !omitempty && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func exported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
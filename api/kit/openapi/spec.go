@@ -0,0 +1,240 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ProblemResponse est la réponse d'erreur partagée (components.responses), au format RFC 9457.
+const ProblemResponse = "Problem"
+
+// Spec collecte la description des opérations. Les vues retournées par Group et Public partagent
+// le même document : chaque module décrit ses routes relativement à son préfixe de montage.
+type Spec struct {
+	state  *specState
+	prefix string
+	public bool
+	tag    string
+}
+
+type specState struct {
+	doc     Document
+	schemas *schemas
+	ops     map[routeKey]*Operation
+	ignored []string
+}
+
+type routeKey struct {
+	method string
+	path   string
+}
+
+// New crée une description vide. problem est le type des réponses d'erreur (api.Problem),
+// servi avec le media type problemContentType.
+func New(info Info, problem any, problemContentType string) *Spec {
+	s := &specState{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Responses:       map[string]*Response{},
+				SecuritySchemes: map[string]*SecurityScheme{},
+			},
+		},
+		schemas: newSchemas(),
+		ops:     map[routeKey]*Operation{},
+	}
+	s.doc.Components.Responses[ProblemResponse] = &Response{
+		Description: "Erreur (RFC 9457)",
+		Content:     map[string]MediaType{problemContentType: {Schema: s.schemas.of(reflect.TypeOf(problem))}},
+	}
+	return &Spec{state: s}
+}
+
+// SecurityScheme déclare un schéma de sécurité. Avec required, il devient l'exigence par défaut
+// des opérations non publiques (plusieurs schémas requis sont des alternatives).
+func (s *Spec) SecurityScheme(name string, scheme SecurityScheme, required bool) {
+	s.state.doc.Components.SecuritySchemes[name] = &scheme
+	if required {
+		s.state.doc.Security = append(s.state.doc.Security, SecurityRequirement{name: {}})
+	}
+}
+
+// Tag décrit un groupe d'opérations (un par module).
+func (s *Spec) Tag(name, description string) {
+	s.state.doc.Tags = append(s.state.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// Group retourne une vue dont les chemins sont préfixés par prefix (routes authentifiées).
+func (s *Spec) Group(prefix string) *Spec {
+	return &Spec{state: s.state, prefix: s.prefix + prefix, public: s.public, tag: s.tag}
+}
+
+// Public retourne une vue préfixée dont les opérations ne demandent pas d'authentification.
+func (s *Spec) Public(prefix string) *Spec {
+	return &Spec{state: s.state, prefix: s.prefix + prefix, public: true, tag: s.tag}
+}
+
+// Tagged retourne une vue dont les opérations sont classées sous tag (déclaré avec Tag).
+func (s *Spec) Tagged(tag string) *Spec {
+	return &Spec{state: s.state, prefix: s.prefix, public: s.public, tag: tag}
+}
+
+// Ignore exclut de la vérification de Build les routes sous prefix (interface de documentation...).
+func (s *Spec) Ignore(prefix string) {
+	s.state.ignored = append(s.state.ignored, s.prefix+prefix)
+}
+
+// Add décrit l'opération method pattern (motif chi, relatif à la vue). Les paramètres de chemin
+// non décrits sont déduits du motif ; la réponse d'erreur commune est ajoutée par Build.
+func (s *Spec) Add(method, pattern string, op Operation) {
+	if s.public && op.Security == nil {
+		op.Security = &[]SecurityRequirement{}
+	}
+	if s.tag != "" && op.Tags == nil {
+		op.Tags = []string{s.tag}
+	}
+	s.state.ops[routeKey{method: method, path: normalize(s.prefix + pattern)}] = &op
+}
+
+// =================================================================================
+// Helpers de description
+// =================================================================================
+
+// Schema retourne le schéma du type de v (référence vers components.schemas pour une structure).
+func (s *Spec) Schema(v any) *Schema {
+	return s.state.schemas.of(reflect.TypeOf(v))
+}
+
+// JSON décrit une réponse JSON dont le corps a la forme de v.
+func (s *Spec) JSON(description string, v any) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: s.Schema(v)}},
+	}
+}
+
+// Body décrit un corps de requête obligatoire de type contentType ("application/json"...).
+func (s *Spec) Body(contentType string, v any) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{contentType: {Schema: s.Schema(v)}},
+	}
+}
+
+// NoContent décrit une réponse sans corps (204...).
+func NoContent(description string) *Response {
+	return &Response{Description: description}
+}
+
+// Query décrit un paramètre d'URL facultatif.
+func Query(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: InQuery, Description: description, Schema: schema}
+}
+
+// HeaderParam décrit un en-tête de requête facultatif.
+func HeaderParam(name, description string) *Parameter {
+	return &Parameter{Name: name, In: InHeader, Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// WithHeader ajoute un en-tête de réponse documenté.
+func (r *Response) WithHeader(name, description string) *Response {
+	if r.Headers == nil {
+		r.Headers = map[string]*Header{}
+	}
+	r.Headers[name] = &Header{Description: description, Schema: &Schema{Type: "string"}}
+	return r
+}
+
+// =================================================================================
+// Assemblage
+// =================================================================================
+
+// pathParam capture les paramètres chi ("{id}", "{id:[0-9]+}").
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Build assemble le document à partir des routes montées. Une route sans description, ou une
+// description sans route, est une erreur : le document ne peut pas diverger du routeur.
+func (s *Spec) Build(routes chi.Routes) (*Document, error) {
+	state := s.state
+	mounted := map[routeKey]bool{}
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := routeKey{method: method, path: normalize(route)}
+		for _, prefix := range state.ignored {
+			if strings.HasPrefix(key.path, prefix) {
+				return nil
+			}
+		}
+		mounted[key] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openapi: walk routes: %w", err)
+	}
+
+	var errs []error
+	for key := range mounted {
+		if _, ok := state.ops[key]; !ok {
+			errs = append(errs, fmt.Errorf("openapi: route %s %s is not documented", key.method, key.path))
+		}
+	}
+	for key := range state.ops {
+		if !mounted[key] {
+			errs = append(errs, fmt.Errorf("openapi: documented operation %s %s has no route", key.method, key.path))
+		}
+	}
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+		return nil, errors.Join(errs...)
+	}
+
+	doc := state.doc
+	doc.Paths = map[string]PathItem{}
+	for key, op := range state.ops {
+		path := pathParam.ReplaceAllString(key.path, "{$1}")
+		op.Parameters = withPathParams(op.Parameters, key.path)
+		if op.Responses == nil {
+			op.Responses = map[string]*Response{}
+		}
+		if _, ok := op.Responses["default"]; !ok {
+			op.Responses["default"] = &Response{Ref: "#/components/responses/" + ProblemResponse}
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(key.method)] = op
+	}
+	doc.Components.Schemas = state.schemas.components
+	return &doc, nil
+}
+
+// withPathParams ajoute en tête les paramètres de chemin du motif qui ne sont pas déjà décrits.
+func withPathParams(params []*Parameter, pattern string) []*Parameter {
+	var out []*Parameter
+	for _, match := range pathParam.FindAllStringSubmatch(pattern, -1) {
+		described := slices.ContainsFunc(params, func(p *Parameter) bool { return p.In == InPath && p.Name == match[1] })
+		if !described {
+			out = append(out, &Parameter{Name: match[1], In: InPath, Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return append(out, params...)
+}
+
+// normalize retire la barre finale des routes chi ("/api/users/" est servi aussi en "/api/users").
+func normalize(route string) string {
+	if len(route) > 1 {
+		return strings.TrimSuffix(route, "/")
+	}
+	return route
+}
@@ -0,0 +1,112 @@
+// Package pagination transforme les jetons de continuation des bases en curseurs opaques pour le client.
+//
+// Un curseur est signé (HMAC-SHA256) et lié au tenant et au filtre qui l'ont produit :
+// il ne peut être ni forgé, ni rejoué sur un autre tenant, ni réutilisé avec d'autres critères.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"test-api/kit/apperr"
+)
+
+// ErrInvalidCursor est renvoyée pour un curseur malformé, altéré ou utilisé hors de son contexte
+// (autre tenant, autres critères). Un curseur n'expire pas : sa durée de vie est celle du jeton
+// de continuation de la base.
+var ErrInvalidCursor = apperr.New(apperr.ErrInvalidInput, "invalid cursor")
+
+// Signer signe et vérifie les curseurs avec une clé HMAC.
+// La clé doit être identique sur toutes les instances (sinon un curseur émis par l'une est refusé par l'autre).
+type Signer struct {
+	key []byte
+}
+
+// NewSigner crée un Signer avec la clé fournie.
+// Une clé vide donne une clé aléatoire propre au processus (suffisant en local, les curseurs
+// ne survivent pas à un redémarrage).
+func NewSigner(key []byte) *Signer {
+	if len(key) == 0 {
+		key = randomKey()
+	}
+	return &Signer{key: append([]byte(nil), key...)}
+}
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("pagination: cannot generate signing key: %v", err))
+	}
+	return key
+}
+
+// payload est le contenu signé d'un curseur.
+type payload struct {
+	// Token est le jeton de continuation brut de la base.
+	Token string `json:"t"`
+	// Binding est l'empreinte du tenant et du filtre.
+	Binding string `json:"b"`
+}
+
+// Encode produit le curseur opaque pour un jeton de continuation.
+// filterKey est une représentation canonique des critères de recherche (hors curseur).
+// Un jeton vide donne un curseur vide (dernière page).
+func (s *Signer) Encode(token, tenantID, filterKey string) string {
+	if token == "" {
+		return ""
+	}
+
+	body, _ := json.Marshal(payload{Token: token, Binding: binding(tenantID, filterKey)})
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(body) + "." + enc.EncodeToString(s.sign(body))
+}
+
+// Decode vérifie le curseur et retourne le jeton de continuation.
+// Un curseur vide donne un jeton vide (première page).
+func (s *Signer) Decode(cursor, tenantID, filterKey string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	enc := base64.RawURLEncoding
+	bodyPart, sigPart, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return "", ErrInvalidCursor
+	}
+	body, err := enc.DecodeString(bodyPart)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, s.sign(body)) {
+		return "", ErrInvalidCursor
+	}
+
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return "", ErrInvalidCursor
+	}
+	if subtle.ConstantTimeCompare([]byte(p.Binding), []byte(binding(tenantID, filterKey))) != 1 {
+		return "", ErrInvalidCursor
+	}
+
+	return p.Token, nil
+}
+
+func (s *Signer) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// binding est l'empreinte (tenant, filtre) : on ne stocke pas les valeurs elles-mêmes dans le curseur.
+func binding(tenantID, filterKey string) string {
+	sum := sha256.Sum256([]byte(tenantID + "\x00" + filterKey))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
@@ -0,0 +1,70 @@
+package pagination_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"test-api/kit/apperr"
+	"test-api/kit/pagination"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	signer := pagination.NewSigner([]byte("test-signing-key"))
+
+	cursor := signer.Encode(`{"token":"+RID:~abc==#RT:1"}`, "tenant-A", "limit=2&nom=Chevalier")
+	require.NotEmpty(t, cursor)
+	assert.NotContains(t, cursor, "RID", "le jeton de la base n'est pas lisible en clair")
+
+	token, err := signer.Decode(cursor, "tenant-A", "limit=2&nom=Chevalier")
+	require.NoError(t, err)
+	assert.Equal(t, `{"token":"+RID:~abc==#RT:1"}`, token)
+
+	// Dernière page et première page : pas de curseur.
+	assert.Empty(t, signer.Encode("", "tenant-A", "limit=2"))
+	token, err = signer.Decode("", "tenant-A", "limit=2")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
+
+func TestCursor_Rejected(t *testing.T) {
+	signer := pagination.NewSigner([]byte("test-signing-key"))
+	const tenantID, filterKey = "tenant-A", "limit=2&nom=Chevalier"
+	cursor := signer.Encode("continuation", tenantID, filterKey)
+
+	body, sig, _ := strings.Cut(cursor, ".")
+	rawSig, err := base64.RawURLEncoding.DecodeString(sig)
+	require.NoError(t, err)
+	rawSig[0] ^= 0xff
+	forgedBody := base64.RawURLEncoding.EncodeToString([]byte(`{"t":"autre","b":"x"}`))
+
+	tests := []struct {
+		name      string
+		cursor    string
+		tenantID  string
+		filterKey string
+	}{
+		{"MAC altéré", body + "." + base64.RawURLEncoding.EncodeToString(rawSig), tenantID, filterKey},
+		{"Contenu remplacé", forgedBody + "." + sig, tenantID, filterKey},
+		{"Autre tenant", cursor, "tenant-B", filterKey},
+		{"Autres critères", cursor, tenantID, "limit=2&nom=Autre"},
+		{"Base64 invalide", "pas du base64!." + sig, tenantID, filterKey},
+		{"Signature absente", body, tenantID, filterKey},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := signer.Decode(tc.cursor, tc.tenantID, tc.filterKey)
+			assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+			assert.ErrorIs(t, err, apperr.ErrInvalidInput, "un curseur invalide est une erreur du client (400)")
+		})
+	}
+
+	t.Run("Autre clé de signature", func(t *testing.T) {
+		_, err := pagination.NewSigner([]byte("another-key")).Decode(cursor, tenantID, filterKey)
+		assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,80 @@
+// Package tracing branche OpenTelemetry : propagation W3C Trace Context (traceparent / tracestate),
+// span serveur par requête, propagation sur les appels sortants et export OTLP ou stdout.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// Exporteurs acceptés par Config.Exporter (mêmes valeurs que OTEL_TRACES_EXPORTER).
+const (
+	ExporterNone    = "none"
+	ExporterStdout  = "console"
+	ExporterOTLP    = "otlp"
+	instrumentation = "test-api/kit/tracing"
+)
+
+// Config décrit l'export des traces.
+type Config struct {
+	// ServiceName identifie l'API dans le backend de traces (cloud_RoleName dans Application Insights).
+	ServiceName string
+	// Exporter vaut ExporterNone (défaut : identifiants générés et propagés, rien n'est exporté),
+	// ExporterStdout (vérification locale) ou ExporterOTLP (collecteur, agent Azure Monitor...).
+	// L'exporteur OTLP lit lui-même OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS...
+	Exporter string
+	// SampleRatio est la proportion des nouvelles traces conservées (de 0 à 1). Une requête
+	// entrante déjà échantillonnée par l'appelant l'est toujours.
+	SampleRatio float64
+}
+
+// Init installe le fournisseur de traces et le propagateur globaux. La fonction retournée
+// vide les spans en attente : à appeler à l'arrêt du serveur.
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "", ExporterNone:
+	case ExporterStdout, "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q (expected %q, %q or %q)", cfg.Exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
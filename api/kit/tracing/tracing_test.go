@@ -0,0 +1,96 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"test-api/kit/tracing"
+)
+
+// setupRecorder installe un fournisseur de traces qui conserve les spans terminés.
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestMiddleware_ServerSpan(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, trace.SpanContextFromContext(r.Context()).IsValid())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	// 1. Contexte entrant valide : la trace de l'appelant est poursuivie.
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /users/{id}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+
+	// 2. traceparent invalide : une nouvelle trace est démarrée.
+	req = httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("traceparent", "00-pas-une-trace-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans = recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.True(t, spans[1].SpanContext().IsValid())
+	assert.False(t, spans[1].Parent().IsValid())
+}
+
+func TestTransport_Propagation(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	var received string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, parent := otel.Tracer("test").Start(t.Context(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/keys", nil)
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: tracing.Transport(nil)}).Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	// L'en-tête reçu désigne le span client, enfant du span en cours.
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	client := spans[0]
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent().SpanID())
+	assert.Equal(t, "00-"+client.SpanContext().TraceID().String()+"-"+client.SpanContext().SpanID().String()+"-01", received)
+	assert.Empty(t, req.Header.Get("traceparent"), "la requête d'origine n'est pas modifiée")
+}
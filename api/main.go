@@ -1,77 +1,351 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
 	"time"
 
+	"test-api/internal/apikey"
+	"test-api/internal/config"
 	"test-api/internal/server"
+	"test-api/internal/session"
 	"test-api/internal/user"
+	"test-api/kit/auth"
+	"test-api/kit/database"
 	"test-api/kit/database/cosmos"
+	"test-api/kit/database/memory"
+	"test-api/kit/health"
+	"test-api/kit/logger"
+	"test-api/kit/metrics"
+	"test-api/kit/pagination"
+	"test-api/kit/tracing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
 )
 
 func main() {
-	server.InitLogger()
+	// Configuration typée (défauts, CONFIG_FILE, environnement) : au moindre réglage invalide,
+	// on s'arrête avec la liste complète des erreurs plutôt que de démarrer à moitié.
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, config.Report(err))
+		os.Exit(1)
+	}
+
+	// Logs JSON sur Stdout (parsés par Azure), niveau et format réglables par LOG_LEVEL / LOG_FORMAT.
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.Log.Level)) // déjà validé par config.Load
+	if err := logger.Init(logger.Config{Level: level, Format: cfg.Log.Format, AccessLogSampleRate: cfg.Log.AccessSampleRate}); err != nil {
+		fmt.Fprintln(os.Stderr, "Configuration des logs invalide :", err)
+		os.Exit(1)
+	}
+
+	// Annulé à la réception de SIGTERM (arrêt de l'instance par l'hôte) ou SIGINT (Ctrl+C).
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	logger.Info(ctx, "Démarrage de l'application...", "config", cfg)
+
+	// Les fonctions d'arrêt (vidage des traces et métriques...) sont appelées une fois les
+	// requêtes en cours drainées.
+	lifecycle := server.NewLifecycle()
+
+	// Vérifications de /health/ready : chaque composant enregistre les siennes ci-dessous.
+	// L'arrêt en cours rend l'instance indisponible, sans délai de cache.
+	checks := health.NewRegistry()
+	checks.Register("shutdown", lifecycle, health.WithCacheTTL(0))
 
-	slog.Info("Démarrage de l'application...")
+	// Traces OpenTelemetry : OTEL_TRACES_EXPORTER=console pour les voir en local, otlp pour les
+	// envoyer au collecteur (Application Insights). Sans exporteur, les identifiants de trace
+	// sont tout de même générés et propagés (corrélation des logs).
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		ServiceName: cfg.Telemetry.ServiceName,
+		Exporter:    cfg.Telemetry.TracesExporter,
+		SampleRatio: cfg.Telemetry.TracesSampleRatio,
+	})
+	if err != nil {
+		logger.Error(ctx, "Impossible d'initialiser les traces", "error", err)
+		os.Exit(1)
+	}
+	lifecycle.OnShutdown(func(shutdownCtx context.Context) error {
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			return fmt.Errorf("échec de l'envoi des dernières traces: %w", err)
+		}
+		return nil
+	})
+
+	// Métriques : /metrics au format Prometheus (protégé par METRICS_TOKEN), et export OTLP
+	// si OTEL_METRICS_EXPORTER contient "otlp".
+	metricsHandler, shutdownMetrics, err := metrics.Init(ctx, metrics.Config{
+		ServiceName: cfg.Telemetry.ServiceName,
+		OTLP:        slices.Contains(cfg.Telemetry.MetricsExporters, "otlp"),
+		Token:       cfg.Telemetry.MetricsToken.Value(),
+	})
+	if err != nil {
+		logger.Error(ctx, "Impossible d'initialiser les métriques", "error", err)
+		os.Exit(1)
+	}
+	if metricsHandler == nil {
+		logger.Warn(ctx, "METRICS_TOKEN absent : l'endpoint /metrics n'est pas exposé")
+	}
+	lifecycle.OnShutdown(func(shutdownCtx context.Context) error {
+		if err := shutdownMetrics(shutdownCtx); err != nil {
+			return fmt.Errorf("échec de l'envoi des dernières métriques: %w", err)
+		}
+		return nil
+	})
+
+	// Client HTTP des appels sortants : le contexte de trace y est propagé (traceparent).
+	httpClient := &http.Client{Transport: tracing.Transport(nil), Timeout: 30 * time.Second}
+	lifecycle.OnShutdown(func(context.Context) error {
+		httpClient.CloseIdleConnections()
+		return nil
+	})
 
 	// =========================================================================
 	// Injection des dépendances
 	// =========================================================================
 
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		slog.Error("Erreur de credential: %v", err)
+	// Sans COSMOS_ENDPOINT (lancement local), on utilise l'adaptateur en mémoire :
+	// même sémantique que Cosmos, mais les données sont perdues à l'arrêt.
+	var userGenericAdapter database.Repository[user.User]
+	var apiKeyGenericAdapter database.Repository[apikey.APIKey]
+	var refreshTokenGenericAdapter database.Repository[session.RefreshToken]
+
+	if cfg.Cosmos.InMemory() {
+		logger.Warn(ctx, "COSMOS_ENDPOINT absent : utilisation de la base en mémoire")
+		userGenericAdapter = memory.NewAdapter[user.User]()
+		apiKeyGenericAdapter = memory.NewAdapter[apikey.APIKey]()
+		refreshTokenGenericAdapter = memory.NewAdapter[session.RefreshToken]()
+	} else {
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			logger.Error(ctx, "Erreur de credential", "error", err)
+			os.Exit(1)
+		}
+
+		client, err := azcosmos.NewClient(cfg.Cosmos.Endpoint, cred, &azcosmos.ClientOptions{
+			ClientOptions: azcore.ClientOptions{Transport: httpClient},
+		})
+		if err != nil {
+			logger.Error(ctx, "Erreur création client Cosmos", "error", err)
+			os.Exit(1)
+		}
+
+		// Le conteneur doit avoir le TTL activé (DefaultTimeToLive = -1) : la purge des utilisateurs
+		// supprimés repose sur le champ "ttl" posé par la suppression logique.
+		usersAdapter, err := cosmos.NewAdapter[user.User](client, cfg.Cosmos.Database, cfg.Cosmos.UsersContainer)
+		if err != nil {
+			logger.Error(ctx, "Impossible d'initialiser l'adaptateur Cosmos pour User", "error", err)
+			os.Exit(1)
+		}
+		userGenericAdapter = usersAdapter
+		checks.Register("cosmos:"+cfg.Cosmos.UsersContainer, health.CheckerFunc(usersAdapter.Ping))
+
+		// Conteneur partitionné par /tenantID, comme celui des utilisateurs.
+		apiKeysAdapter, err := cosmos.NewAdapter[apikey.APIKey](client, cfg.Cosmos.Database, cfg.Cosmos.APIKeysContainer)
+		if err != nil {
+			logger.Error(ctx, "Impossible d'initialiser l'adaptateur Cosmos pour APIKey", "error", err)
+			os.Exit(1)
+		}
+		apiKeyGenericAdapter = apiKeysAdapter
+		checks.Register("cosmos:"+cfg.Cosmos.APIKeysContainer, health.CheckerFunc(apiKeysAdapter.Ping))
+
+		// TTL activé sur le conteneur : les jetons expirés sont purgés par la base.
+		refreshTokensAdapter, err := cosmos.NewAdapter[session.RefreshToken](client, cfg.Cosmos.Database, cfg.Cosmos.RefreshTokensContainer)
+		if err != nil {
+			logger.Error(ctx, "Impossible d'initialiser l'adaptateur Cosmos pour RefreshToken", "error", err)
+			os.Exit(1)
+		}
+		refreshTokenGenericAdapter = refreshTokensAdapter
+		checks.Register("cosmos:"+cfg.Cosmos.RefreshTokensContainer, health.CheckerFunc(refreshTokensAdapter.Ping))
 	}
 
-	endpoint := os.Getenv("COSMOS_ENDPOINT")
-	// TODO: Dans un vrai projet, validez que endpoint n'est pas vide
-	client, err := azcosmos.NewClient(endpoint, cred, nil)
-	if err != nil {
-		slog.Error("Erreur création client Cosmos: %v", err)
+	// Clé de signature des curseurs de pagination : doit être partagée par toutes les instances
+	// (obligatoire avec Cosmos, voir config.Validate).
+	if cfg.Pagination.CursorSigningKey.Value() == "" {
+		logger.Warn(ctx, "CURSOR_SIGNING_KEY absent (stockage en mémoire) : clé aléatoire, les curseurs ne survivront pas à un redémarrage")
 	}
+	cursors := pagination.NewSigner([]byte(cfg.Pagination.CursorSigningKey.Value()))
+
+	apiKeyService := apikey.NewService(apiKeyGenericAdapter)
 
-	userGenericAdapter, err := cosmos.NewAdapter[user.User](client, "TestDB", "UsersContainer")
+	// Authentification : Entra ID (JWKS) si configuré, sinon secret partagé (jetons HMAC).
+	// Les intégrations machine à machine utilisent des clés d'API.
+	authenticator, err := newAuthenticator(cfg.Auth, httpClient, checks, auth.WithAPIKeys(apiKeyService))
 	if err != nil {
-		slog.Error("Impossible d'initialiser l'adaptateur Cosmos pour User: %v", err)
+		logger.Error(ctx, "Configuration de l'authentification invalide", "error", err)
+		os.Exit(1)
 	}
 
-	// TODO on peut si besoin rajouter une petite methode setup dans le domaine user pour garder le main propre
-	// ( A voir si on fait un fichier spécifique ou bien juste rajouter dans le handler)
 	userRepo := user.NewCosmosRepository(userGenericAdapter)
-	userService := user.NewService(userRepo)
-	userHandler := user.NewHandler(userService)
+	userService := user.NewService(userRepo, invitationOptions(ctx, cfg.Invitation)...)
+
+	// Modules métier montés par le routeur, choisis par MODULES : un nouveau domaine s'ajoute
+	// au catalogue, sans toucher au routeur.
+	catalog := []server.ModuleFactory{
+		{Name: user.ModuleName, New: func() (server.Module, error) {
+			return user.NewModule(userService, cursors), nil
+		}},
+		{Name: apikey.ModuleName, New: func() (server.Module, error) {
+			return apikey.NewModule(apiKeyService), nil
+		}},
+		// Connexion locale (email + mot de passe) : les jetons d'accès émis sont signés avec
+		// JWT_SECRET, elle n'est donc disponible que lorsque l'API valide les jetons HMAC.
+		{Name: session.ModuleName, New: func() (server.Module, error) {
+			if cfg.Auth.UsesJWKS() {
+				return nil, errors.New("local login requires JWT_SECRET, tokens are validated with JWKS")
+			}
+			issuer, err := auth.NewTokenIssuer([]byte(cfg.Auth.JWTSecret.Value()), localTokenIssuer, cfg.Auth.Audience, auth.DefaultAccessTokenTTL)
+			if err != nil {
+				return nil, err
+			}
+			return session.NewModule(session.NewService(refreshTokenGenericAdapter, userService, issuer)), nil
+		}},
+	}
+	enabled := cfg.Modules.Enabled
+	if len(enabled) == 0 {
+		enabled = []string{user.ModuleName, apikey.ModuleName}
+		if !cfg.Auth.UsesJWKS() {
+			enabled = append(enabled, session.ModuleName)
+		}
+	}
+	modules, err := server.BuildModules(catalog, enabled)
+	if err != nil {
+		logger.Error(ctx, "Modules métier invalides (MODULES)", "error", err)
+		os.Exit(1)
+	}
+
+	registry, err := server.NewRegistry(modules...)
+	if err != nil {
+		logger.Error(ctx, "Modules métier incohérents", "error", err)
+		os.Exit(1)
+	}
+	registry.RegisterHealthChecks(checks)
+	// Enregistrées après les fonctions d'arrêt des traces et métriques : les tâches de fond
+	// sont arrêtées avant leur vidage.
+	registry.StartJobs(lifecycle)
 
 	// =========================================================================
 	// Configuration du Routeur HTTP (Chi)
 	// =========================================================================
 
-	httpHandler := server.NewRouter(userHandler)
+	httpHandler, err := server.NewRouter(registry, authenticator, metricsHandler, checks)
+	if err != nil {
+		logger.Error(ctx, "Impossible de construire le routeur", "error", err)
+		os.Exit(1)
+	}
 
 	// =========================================================================
 	// Configuration et démarrage du serveur
 	// =========================================================================
-	port := os.Getenv("FUNCTIONS_CUSTOMHANDLER_PORT")
-	if port == "" {
-		port = "8080"
+	addr := ":" + strconv.Itoa(cfg.Server.Port)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           httpHandler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		// Les requêtes en cours gardent leur contexte pendant le drainage : seul le délai
+		// d'arrêt (SERVER_SHUTDOWN_TIMEOUT) les interrompt.
+		BaseContext: func(net.Listener) context.Context { return context.Background() },
 	}
 
-	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      httpHandler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	// Écoute ouverte avant de se déclarer prêt : un port déjà pris fait échouer le démarrage.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Error(ctx, "Impossible d'écouter sur le port", "addr", addr, "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info(ctx, "Serveur lancé", "addr", "http://localhost"+addr)
+
+	err = lifecycle.Run(ctx, srv, ln, server.ShutdownConfig{
+		DrainDelay: cfg.Server.DrainDelay,
+		Timeout:    cfg.Server.ShutdownTimeout,
+	})
+	if err != nil {
+		logger.Error(context.Background(), "Arrêt en erreur", "error", err)
+		os.Exit(1)
+	}
+}
+
+// localTokenIssuer est l'émetteur ("iss") des jetons délivrés par /api/auth/login.
+const localTokenIssuer = "test-api"
+
+// newAuthenticator construit la validation des jetons :
+//   - JWKS (URL ou fichier) : jetons RS256/ES256 (Entra ID), avec émetteurs ("{tenantid}" autorisé)
+//     et audience obligatoires (vérifié par config.Load) ;
+//   - sinon JWT_SECRET : jetons HMAC émis par nos soins (iss = localTokenIssuer, toujours
+//     accepté) ; tout autre émetteur doit être listé dans AUTH_ISSUERS.
+//
+// La politique rôles/permissions par tenant vient de AUTH_ROLES_FILE (auth.DefaultRoles sinon).
+// La fraîcheur des clés distantes est ajoutée aux vérifications de santé (non critique : les
+// clés en cache restent utilisables).
+func newAuthenticator(cfg config.Auth, httpClient *http.Client, checks *health.Registry, opts ...auth.Option) (*auth.Authenticator, error) {
+	if cfg.RolesFile != "" {
+		policy, err := auth.LoadPolicyFile(cfg.RolesFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, auth.WithPolicy(policy))
 	}
+	if len(cfg.Issuers) > 0 {
+		opts = append(opts, auth.WithIssuers(cfg.Issuers...))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, auth.WithAudience(cfg.Audience))
+	}
+	opts = append(opts, auth.WithLeeway(cfg.ClockSkew))
 
-	fmt.Println("Serveur lancé sur http://localhost:" + port)
+	switch {
+	case cfg.JWKSURL != "":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		keys, err := auth.NewRemoteJWKS(ctx, cfg.JWKSURL, auth.WithHTTPClient(httpClient))
+		if err != nil {
+			return nil, err
+		}
+		checks.Register("jwks", keys, health.NonCritical())
+		return auth.NewJWKSAuthenticator(keys, opts...)
+	case cfg.JWKSFile != "":
+		keys, err := auth.LoadJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewJWKSAuthenticator(keys, opts...)
+	default:
+		opts = append(opts, auth.WithIssuers(localTokenIssuer))
+		return auth.NewHMACAuthenticator([]byte(cfg.JWTSecret.Value()), opts...)
+	}
+}
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatal("Erreur serveur : ", err)
+// invitationOptions configure l'envoi des invitations. La clé de signature n'est facultative
+// qu'en mémoire (config.Validate) : les invitations ne survivent alors pas à un redémarrage.
+func invitationOptions(ctx context.Context, cfg config.Invitation) []user.ServiceOption {
+	opts := []user.ServiceOption{
+		user.WithInvitationBaseURL(cfg.BaseURL),
+		user.WithInvitationTTL(cfg.TTL),
+	}
+	if key := cfg.SigningKey.Value(); key != "" {
+		opts = append(opts, user.WithInvitationSigningKey([]byte(key)))
+	} else {
+		logger.Warn(ctx, "INVITATION_SIGNING_KEY absent (stockage en mémoire) : clé aléatoire, les invitations ne survivront pas à un redémarrage")
+	}
+	if cfg.Dir != "" {
+		opts = append(opts, user.WithNotifier(user.FileNotifier{Dir: cfg.Dir}))
 	}
+	return opts
 }
@@ -1,7 +1,9 @@
 import { client } from '../../lib/api';
-import { UserApiResponse } from './users.types';
+import { UserApiResponse, UserSearchResponse } from './users.types';
 
 // La fonction métier spécifique
+// L'API pagine par curseur : on ne récupère ici que la première page.
 export const fetchUsers = async (): Promise<UserApiResponse[]> => {
-  return client<UserApiResponse[]>('/api/users');
-};
\ No newline at end of file
+  const page = await client<UserSearchResponse>('/api/users');
+  return page.items;
+};
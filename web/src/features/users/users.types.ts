@@ -8,4 +8,9 @@ export interface UserApiResponse {
     // J'ajoute 'role' en optionnel au cas où votre API évolue,
     // mais l'exemple JSON fourni n'en avait pas.
     role?: string;
-}
\ No newline at end of file
+}
+// Réponse paginée de GET /api/users : passer nextCursor en ?cursor= pour la page suivante.
+export interface UserSearchResponse {
+    items: UserApiResponse[];
+    nextCursor?: string;
+}
@@ -1,11 +1,51 @@
 export const API_BASE_URL = import.meta.env.VITE_API_URL;
 
+// Erreur de validation sur un champ, telle que renvoyée dans "errors" par l'API Go.
+export interface FieldError {
+  field: string;
+  message: string;
+}
+
+// Document d'erreur RFC 9457 (application/problem+json) renvoyé par kit/api.
+export interface ProblemDetails {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  errors?: FieldError[];
+}
+
+export class ApiError extends Error {
+  constructor(public readonly problem: ProblemDetails) {
+    super(problem.detail ?? problem.title);
+    this.name = 'ApiError';
+  }
+
+  // Message d'erreur pour un champ de formulaire donné (undefined si le champ est valide).
+  fieldError(field: string): string | undefined {
+    return this.problem.errors?.find((e) => e.field === field)?.message;
+  }
+}
+
+const toApiError = async (response: Response): Promise<ApiError> => {
+  const contentType = response.headers.get('Content-Type') ?? '';
+  if (contentType.includes('application/problem+json')) {
+    return new ApiError((await response.json()) as ProblemDetails);
+  }
+  return new ApiError({
+    type: 'about:blank',
+    title: response.statusText,
+    status: response.status,
+    detail: `Erreur HTTP api: ${response.status}`,
+  });
+};
 
 export const client = async <T>(endpoint: string): Promise<T> => {
   const response = await fetch(`${API_BASE_URL}${endpoint}`);
   if (!response.ok) {
-    throw new Error(`Erreur HTTP api: ${response.status}`);
+    throw await toApiError(response);
   }
 
   return response.json() as Promise<T>;
-};
\ No newline at end of file
+};